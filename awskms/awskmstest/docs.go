@@ -0,0 +1,6 @@
+// Package awskmstest provides a fake AWS KMS server for testing code that depends on the awskms package, without
+// any AWS credentials or network access.
+//
+// The Server speaks the AWS KMS JSON protocol and is backed by in-memory keys. Only the operations needed by the
+// awskms package are implemented.
+package awskmstest
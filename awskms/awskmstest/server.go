@@ -0,0 +1,611 @@
+package awskmstest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"github.com/LampardNguyen234/evm-kms/awskms"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Region is the region of the fake server.
+	Region = "us-west-1"
+
+	// AccountID is the AWS account ID of the fake server.
+	AccountID = "111122223333"
+
+	targetPrefix = "TrentService."
+)
+
+// key is an in-memory KMS key.
+type key struct {
+	metadata   kmstypes.KeyMetadata
+	privateKey *ecdsa.PrivateKey
+	tags       map[string]string
+
+	// denied indicates whether the requests on the key are denied, e.g. for a key of another account.
+	denied bool
+}
+
+// Server is a fake AWS KMS server, backed by in-memory keys.
+type Server struct {
+	*httptest.Server
+
+	mtx     sync.RWMutex
+	keys    map[string]*key
+	aliases map[string]string
+	counter int
+	errType string
+}
+
+// NewServer starts and returns a new Server. The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{keys: make(map[string]*key), aliases: make(map[string]string)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// NewKey creates a new enabled secp256k1 signing key and returns its ID.
+func (s *Server) NewKey() string {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+
+	return s.AddKey(privateKey)
+}
+
+// AddKey adds the given private key as an enabled signing key and returns its ID. The key spec is derived from the
+// curve of the private key (secp256k1 or P-256).
+func (s *Server) AddKey(privateKey *ecdsa.PrivateKey) string {
+	keySpec := kmstypes.KeySpecEccSecgP256k1
+	if privateKey.Curve == elliptic.P256() {
+		keySpec = kmstypes.KeySpecEccNistP256
+	}
+
+	return s.addKey(privateKey, keySpec, kmstypes.KeyUsageTypeSignVerify, "")
+}
+
+// KMSClient returns a kms.Client pointed at the Server.
+func (s *Server) KMSClient() *kms.Client {
+	return kms.New(kms.Options{
+		Region:           Region,
+		Credentials:      credentials.NewStaticCredentialsProvider("ACCESS_KEY_ID", "SECRET_ACCESS_KEY", ""),
+		EndpointResolver: kms.EndpointResolverFromURL(s.URL),
+		Retryer:          aws.NopRetryer{},
+		HTTPClient:       s.Client(),
+	})
+}
+
+// NewAmazonKMSClient creates an awskms.AmazonKMSClient pointed at the Server with the given config. If cfg.KeyID is
+// empty, a new key is created.
+func (s *Server) NewAmazonKMSClient(ctx context.Context, cfg awskms.Config, txSigner ...types.Signer) (*awskms.AmazonKMSClient, error) {
+	if cfg.KeyID == "" {
+		cfg.KeyID = s.NewKey()
+	}
+
+	return awskms.NewAmazonKMSClient(ctx, cfg, s.KMSClient(), txSigner...)
+}
+
+// DisableKey disables the key with the given ID.
+func (s *Server) DisableKey(keyID string) error {
+	k, err := s.getKey(keyID)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	k = s.keys[*k.metadata.KeyId]
+	k.metadata.Enabled = false
+	k.metadata.KeyState = kmstypes.KeyStateDisabled
+
+	return nil
+}
+
+// DenyKey makes all subsequent requests on the key with the given ID fail with an AccessDeniedException. The key is
+// still listed by ListKeys.
+func (s *Server) DenyKey(keyID string) error {
+	k, err := s.getKey(keyID)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.keys[*k.metadata.KeyId].denied = true
+
+	return nil
+}
+
+// SetError makes all subsequent requests fail with the given AWS KMS error type (e.g, "ThrottlingException"), until it
+// is called with an empty errType.
+func (s *Server) SetError(errType string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.errType = errType
+}
+
+func (s *Server) addKey(privateKey *ecdsa.PrivateKey, keySpec kmstypes.KeySpec, keyUsage kmstypes.KeyUsageType, description string) string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.counter++
+	keyID := fmt.Sprintf("00000000-0000-0000-0000-%012d", s.counter)
+	s.keys[keyID] = &key{
+		metadata: kmstypes.KeyMetadata{
+			KeyId:                 aws.String(keyID),
+			Arn:                   aws.String(fmt.Sprintf("arn:aws:kms:%v:%v:key/%v", Region, AccountID, keyID)),
+			AWSAccountId:          aws.String(AccountID),
+			CreationDate:          aws.Time(time.Now()),
+			Description:           aws.String(description),
+			Enabled:               true,
+			KeyState:              kmstypes.KeyStateEnabled,
+			KeySpec:               keySpec,
+			CustomerMasterKeySpec: kmstypes.CustomerMasterKeySpec(keySpec),
+			KeyUsage:              keyUsage,
+			KeyManager:            kmstypes.KeyManagerTypeCustomer,
+			Origin:                kmstypes.OriginTypeAwsKms,
+			SigningAlgorithms:     []kmstypes.SigningAlgorithmSpec{kmstypes.SigningAlgorithmSpecEcdsaSha256},
+		},
+		privateKey: privateKey,
+	}
+
+	return keyID
+}
+
+// getKey returns a copy of the key with the given ID or ARN.
+func (s *Server) getKey(keyID string) (*key, error) {
+	if keyID == "" {
+		return nil, newError("ValidationException", "KeyId is required")
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if i := strings.Index(keyID, "alias/"); i >= 0 {
+		targetKeyID, ok := s.aliases[keyID[i:]]
+		if !ok {
+			return nil, newError("NotFoundException", fmt.Sprintf("Alias '%v' does not exist", keyID[i:]))
+		}
+		keyID = targetKeyID
+	}
+	keyID = keyID[strings.LastIndex(keyID, "/")+1:]
+
+	k, ok := s.keys[keyID]
+	if !ok {
+		return nil, newError("NotFoundException", fmt.Sprintf("Key '%v' does not exist", keyID))
+	}
+	if k.denied {
+		return nil, newError("AccessDeniedException", fmt.Sprintf("User is not authorized to access key '%v'", keyID))
+	}
+	kCopy := *k
+
+	return &kCopy, nil
+}
+
+// apiError is an error of the AWS KMS JSON protocol.
+type apiError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func newError(errType, msg string) *apiError {
+	return &apiError{Type: errType, Message: msg}
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Type, e.Message)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	var resp interface{}
+	var err error
+
+	s.mtx.RLock()
+	errType := s.errType
+	s.mtx.RUnlock()
+
+	target := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix)
+	switch {
+	case errType != "":
+		err = newError(errType, "injected error")
+	case target == "GetPublicKey":
+		resp, err = s.getPublicKey(r)
+	case target == "Sign":
+		resp, err = s.sign(r)
+	case target == "CreateKey":
+		resp, err = s.createKey(r)
+	case target == "DescribeKey":
+		resp, err = s.describeKey(r)
+	case target == "CreateAlias":
+		resp, err = s.createAlias(r)
+	case target == "ScheduleKeyDeletion":
+		resp, err = s.scheduleKeyDeletion(r)
+	case target == "ListKeys":
+		resp, err = s.listKeys(r)
+	case target == "ListAliases":
+		resp, err = s.listAliases(r)
+	case target == "ListResourceTags":
+		resp, err = s.listResourceTags(r)
+	default:
+		err = newError("UnknownOperationException", fmt.Sprintf("operation %v not supported", target))
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	if err != nil {
+		apiErr, ok := err.(*apiError)
+		if !ok {
+			apiErr = newError("KMSInternalException", err.Error())
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(apiErr)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func decodeRequest(r *http.Request, req interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return newError("SerializationException", err.Error())
+	}
+	return nil
+}
+
+func (s *Server) getPublicKey(r *http.Request) (interface{}, error) {
+	var req struct {
+		KeyId string
+	}
+	if err := decodeRequest(r, &req); err != nil {
+		return nil, err
+	}
+
+	k, err := s.getKey(req.KeyId)
+	if err != nil {
+		return nil, err
+	}
+	if k.metadata.KeyState != kmstypes.KeyStateEnabled {
+		return nil, newError("DisabledException", fmt.Sprintf("%v is disabled", *k.metadata.Arn))
+	}
+
+	var der []byte
+	if k.metadata.KeySpec == kmstypes.KeySpecEccSecgP256k1 {
+		der, err = common2.MarshalPKIXPublicKey(&k.privateKey.PublicKey)
+	} else {
+		der, err = x509.MarshalPKIXPublicKey(&k.privateKey.PublicKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"KeyId":                 k.metadata.Arn,
+		"PublicKey":             der,
+		"KeySpec":               k.metadata.KeySpec,
+		"CustomerMasterKeySpec": k.metadata.CustomerMasterKeySpec,
+		"KeyUsage":              k.metadata.KeyUsage,
+		"SigningAlgorithms":     k.metadata.SigningAlgorithms,
+	}, nil
+}
+
+func (s *Server) sign(r *http.Request) (interface{}, error) {
+	var req struct {
+		KeyId            string
+		Message          []byte
+		MessageType      string
+		SigningAlgorithm string
+	}
+	if err := decodeRequest(r, &req); err != nil {
+		return nil, err
+	}
+
+	k, err := s.getKey(req.KeyId)
+	if err != nil {
+		return nil, err
+	}
+	if k.metadata.KeyState != kmstypes.KeyStateEnabled {
+		return nil, newError("DisabledException", fmt.Sprintf("%v is disabled", *k.metadata.Arn))
+	}
+	if k.metadata.KeyUsage != kmstypes.KeyUsageTypeSignVerify {
+		return nil, newError("InvalidKeyUsageException", "key usage must be SIGN_VERIFY")
+	}
+	if req.SigningAlgorithm != string(kmstypes.SigningAlgorithmSpecEcdsaSha256) {
+		return nil, newError("InvalidKeyUsageException", fmt.Sprintf("unsupported signing algorithm %v", req.SigningAlgorithm))
+	}
+	if req.MessageType != string(kmstypes.MessageTypeDigest) || len(req.Message) != 32 {
+		return nil, newError("ValidationException", "only 32-byte digests are supported")
+	}
+
+	var der []byte
+	if k.metadata.KeySpec == kmstypes.KeySpecEccSecgP256k1 {
+		sig, err := crypto.Sign(req.Message, k.privateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		// the real KMS does not enforce a low S, so half of the signatures are returned in their high-S form.
+		rr := new(big.Int).SetBytes(sig[:32])
+		ss := new(big.Int).SetBytes(sig[32:64])
+		if req.Message[0]%2 == 1 {
+			ss = new(big.Int).Sub(common2.CurveOrder, ss)
+		}
+		der, err = asn1.Marshal(common2.KmsSignature{R: rr, S: ss})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		der, err = ecdsa.SignASN1(rand.Reader, k.privateKey, req.Message)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{
+		"KeyId":            k.metadata.Arn,
+		"Signature":        der,
+		"SigningAlgorithm": req.SigningAlgorithm,
+	}, nil
+}
+
+func (s *Server) createKey(r *http.Request) (interface{}, error) {
+	var req struct {
+		KeySpec     string
+		KeyUsage    string
+		Description string
+		Tags        []struct {
+			TagKey   string
+			TagValue string
+		}
+	}
+	if err := decodeRequest(r, &req); err != nil {
+		return nil, err
+	}
+
+	keyUsage := kmstypes.KeyUsageType(req.KeyUsage)
+	if keyUsage == "" {
+		keyUsage = kmstypes.KeyUsageTypeEncryptDecrypt
+	}
+
+	var privateKey *ecdsa.PrivateKey
+	var err error
+	switch kmstypes.KeySpec(req.KeySpec) {
+	case kmstypes.KeySpecEccSecgP256k1:
+		privateKey, err = crypto.GenerateKey()
+	case kmstypes.KeySpecEccNistP256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, newError("UnsupportedOperationException", fmt.Sprintf("unsupported key spec %v", req.KeySpec))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keyID := s.addKey(privateKey, kmstypes.KeySpec(req.KeySpec), keyUsage, req.Description)
+
+	s.mtx.Lock()
+	s.keys[keyID].tags = make(map[string]string)
+	for _, tag := range req.Tags {
+		s.keys[keyID].tags[tag.TagKey] = tag.TagValue
+	}
+	s.mtx.Unlock()
+
+	return s.keyMetadataResponse(keyID)
+}
+
+func (s *Server) createAlias(r *http.Request) (interface{}, error) {
+	var req struct {
+		AliasName   string
+		TargetKeyId string
+	}
+	if err := decodeRequest(r, &req); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(req.AliasName, "alias/") || strings.HasPrefix(req.AliasName, "alias/aws/") {
+		return nil, newError("ValidationException", fmt.Sprintf("invalid alias name %v", req.AliasName))
+	}
+	k, err := s.getKey(req.TargetKeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.aliases[req.AliasName]; ok {
+		return nil, newError("AlreadyExistsException", fmt.Sprintf("An alias with the name %v already exists", req.AliasName))
+	}
+	s.aliases[req.AliasName] = *k.metadata.KeyId
+
+	return map[string]interface{}{}, nil
+}
+
+func (s *Server) scheduleKeyDeletion(r *http.Request) (interface{}, error) {
+	var req struct {
+		KeyId               string
+		PendingWindowInDays *int32
+	}
+	if err := decodeRequest(r, &req); err != nil {
+		return nil, err
+	}
+
+	pendingWindowInDays := int32(30)
+	if req.PendingWindowInDays != nil {
+		pendingWindowInDays = *req.PendingWindowInDays
+	}
+	if pendingWindowInDays < 7 || pendingWindowInDays > 30 {
+		return nil, newError("ValidationException", fmt.Sprintf("invalid PendingWindowInDays %v", pendingWindowInDays))
+	}
+	k, err := s.getKey(req.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	k = s.keys[*k.metadata.KeyId]
+	k.metadata.Enabled = false
+	k.metadata.KeyState = kmstypes.KeyStatePendingDeletion
+	k.metadata.DeletionDate = aws.Time(time.Now().AddDate(0, 0, int(pendingWindowInDays)))
+
+	return map[string]interface{}{
+		"KeyId":               k.metadata.Arn,
+		"KeyState":            k.metadata.KeyState,
+		"DeletionDate":        float64(k.metadata.DeletionDate.UnixNano()) / 1e9,
+		"PendingWindowInDays": pendingWindowInDays,
+	}, nil
+}
+
+func (s *Server) describeKey(r *http.Request) (interface{}, error) {
+	var req struct {
+		KeyId string
+	}
+	if err := decodeRequest(r, &req); err != nil {
+		return nil, err
+	}
+
+	return s.keyMetadataResponse(req.KeyId)
+}
+
+// listKeys returns all keys in a single page, ordered by key ID.
+func (s *Server) listKeys(r *http.Request) (interface{}, error) {
+	var req struct{}
+	if err := decodeRequest(r, &req); err != nil {
+		return nil, err
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	keyIDs := make([]string, 0, len(s.keys))
+	for keyID := range s.keys {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+
+	keys := make([]map[string]interface{}, 0, len(keyIDs))
+	for _, keyID := range keyIDs {
+		keys = append(keys, map[string]interface{}{
+			"KeyId":  keyID,
+			"KeyArn": s.keys[keyID].metadata.Arn,
+		})
+	}
+
+	return map[string]interface{}{
+		"Keys":      keys,
+		"Truncated": false,
+	}, nil
+}
+
+// listAliases returns all aliases in a single page, ordered by alias name.
+func (s *Server) listAliases(r *http.Request) (interface{}, error) {
+	var req struct{}
+	if err := decodeRequest(r, &req); err != nil {
+		return nil, err
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	aliasNames := make([]string, 0, len(s.aliases))
+	for aliasName := range s.aliases {
+		aliasNames = append(aliasNames, aliasName)
+	}
+	sort.Strings(aliasNames)
+
+	aliases := make([]map[string]interface{}, 0, len(aliasNames))
+	for _, aliasName := range aliasNames {
+		aliases = append(aliases, map[string]interface{}{
+			"AliasName":   aliasName,
+			"AliasArn":    fmt.Sprintf("arn:aws:kms:%v:%v:%v", Region, AccountID, aliasName),
+			"TargetKeyId": s.aliases[aliasName],
+		})
+	}
+
+	return map[string]interface{}{
+		"Aliases":   aliases,
+		"Truncated": false,
+	}, nil
+}
+
+// listResourceTags returns all tags of a key in a single page, ordered by tag key.
+func (s *Server) listResourceTags(r *http.Request) (interface{}, error) {
+	var req struct {
+		KeyId string
+	}
+	if err := decodeRequest(r, &req); err != nil {
+		return nil, err
+	}
+
+	k, err := s.getKey(req.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	tagKeys := make([]string, 0, len(k.tags))
+	for tagKey := range k.tags {
+		tagKeys = append(tagKeys, tagKey)
+	}
+	sort.Strings(tagKeys)
+
+	tags := make([]map[string]interface{}, 0, len(tagKeys))
+	for _, tagKey := range tagKeys {
+		tags = append(tags, map[string]interface{}{
+			"TagKey":   tagKey,
+			"TagValue": k.tags[tagKey],
+		})
+	}
+
+	return map[string]interface{}{
+		"Tags":      tags,
+		"Truncated": false,
+	}, nil
+}
+
+func (s *Server) keyMetadataResponse(keyID string) (interface{}, error) {
+	k, err := s.getKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	m := k.metadata
+
+	return map[string]interface{}{
+		"KeyMetadata": map[string]interface{}{
+			"KeyId":                 m.KeyId,
+			"Arn":                   m.Arn,
+			"AWSAccountId":          m.AWSAccountId,
+			"CreationDate":          float64(m.CreationDate.UnixNano()) / 1e9,
+			"Description":           m.Description,
+			"Enabled":               m.Enabled,
+			"KeyState":              m.KeyState,
+			"KeySpec":               m.KeySpec,
+			"CustomerMasterKeySpec": m.CustomerMasterKeySpec,
+			"KeyUsage":              m.KeyUsage,
+			"KeyManager":            m.KeyManager,
+			"Origin":                m.Origin,
+			"SigningAlgorithms":     m.SigningAlgorithms,
+		},
+	}, nil
+}
@@ -0,0 +1,414 @@
+package awskmstest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	evmkms "github.com/LampardNguyen234/evm-kms"
+	"github.com/LampardNguyen234/evm-kms/awskms"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/LampardNguyen234/evm-kms/kmstest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+)
+
+var receiverAddr = common.HexToAddress("0x243e9517a24813a2d73e9a74cd2c1c699d0ff7a5")
+
+func TestServer_SignHash(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	keyID := s.AddKey(privateKey)
+
+	c, err := s.NewAmazonKMSClient(context.Background(), awskms.Config{KeyID: keyID, ChainID: 80001})
+	if err != nil {
+		panic(err)
+	}
+	if c.GetAddress() != crypto.PubkeyToAddress(privateKey.PublicKey) {
+		panic("invalid address")
+	}
+
+	for i := 0; i < 10; i++ {
+		digest := crypto.Keccak256Hash([]byte{byte(i)})
+		sig, err := c.SignHash(digest)
+		if err != nil {
+			panic(err)
+		}
+
+		pubKey, err := crypto.SigToPub(digest[:], sig)
+		if err != nil {
+			panic(err)
+		}
+		if crypto.PubkeyToAddress(*pubKey) != c.GetAddress() {
+			panic("invalid signature")
+		}
+	}
+}
+
+func TestServer_GetEVMSignerFn(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	c, err := s.NewAmazonKMSClient(context.Background(), awskms.Config{ChainID: 80001})
+	if err != nil {
+		panic(err)
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(80001),
+		To:        &receiverAddr,
+		Nonce:     1,
+		GasTipCap: big.NewInt(1000000),
+		GasFeeCap: big.NewInt(2000000),
+		Gas:       50000,
+		Value:     big.NewInt(100),
+	})
+	signedTx, err := c.GetEVMSignerFn()(c.GetAddress(), tx)
+	if err != nil {
+		panic(err)
+	}
+
+	signed, err := c.HasSignedTx(signedTx)
+	if err != nil {
+		panic(err)
+	}
+	if !signed {
+		panic("transaction not signed by the client")
+	}
+
+	if _, err = c.GetEVMSignerFn()(receiverAddr, tx); err != bind.ErrNotAuthorized {
+		panic("expected bind.ErrNotAuthorized")
+	}
+}
+
+func TestServer_UnknownKey(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	if _, err := s.NewAmazonKMSClient(context.Background(), awskms.Config{KeyID: "unknown", ChainID: 80001}); err == nil {
+		panic("expected an error with an unknown key")
+	}
+}
+
+func TestServer_CreateKey(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	ctx := context.Background()
+	kmsClient := s.KMSClient()
+	createOutput, err := kmsClient.CreateKey(ctx, &kms.CreateKeyInput{
+		KeySpec:     kmstypes.KeySpecEccSecgP256k1,
+		KeyUsage:    kmstypes.KeyUsageTypeSignVerify,
+		Description: aws.String("test key"),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	describeOutput, err := kmsClient.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: createOutput.KeyMetadata.Arn})
+	if err != nil {
+		panic(err)
+	}
+	if *describeOutput.KeyMetadata.KeyId != *createOutput.KeyMetadata.KeyId {
+		panic("invalid key ID")
+	}
+	if describeOutput.KeyMetadata.KeySpec != kmstypes.KeySpecEccSecgP256k1 ||
+		describeOutput.KeyMetadata.KeyState != kmstypes.KeyStateEnabled ||
+		*describeOutput.KeyMetadata.Description != "test key" {
+		panic("invalid key metadata")
+	}
+
+	if _, err = awskms.NewAmazonKMSClient(ctx, awskms.Config{KeyID: *createOutput.KeyMetadata.KeyId, ChainID: 80001}, kmsClient); err != nil {
+		panic(err)
+	}
+}
+
+func TestServer_Conformance(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	kmstest.RunConformance(t, func(t *testing.T) evmkms.KMSSigner {
+		c, err := s.NewAmazonKMSClient(context.Background(), awskms.Config{ChainID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return c
+	})
+}
+
+func TestServer_Endpoint(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	cfg := awskms.StaticCredentialsConfig{
+		Config:          awskms.Config{KeyID: s.NewKey(), ChainID: 80001},
+		Region:          Region,
+		AccessKeyID:     "ACCESS_KEY_ID",
+		SecretAccessKey: "SECRET_ACCESS_KEY",
+		Endpoint:        s.URL,
+	}
+	c, err := awskms.NewAmazonKMSClientWithStaticCredentials(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+	if _, err = c.SignHash(digest); err != nil {
+		panic(err)
+	}
+
+	cfg.Endpoint = "localhost"
+	if _, err = awskms.NewAmazonKMSClientWithStaticCredentials(context.Background(), cfg); err == nil {
+		panic("expected an error with an invalid endpoint")
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	ctx := context.Background()
+	if _, err := s.NewAmazonKMSClient(ctx, awskms.Config{KeyID: "unknown", ChainID: 80001}); !errors.Is(err, common2.ErrKeyNotFound) {
+		panic(fmt.Sprintf("expected ErrKeyNotFound, got %v", err))
+	} else {
+		var notFoundErr *kmstypes.NotFoundException
+		if !errors.As(err, &notFoundErr) {
+			panic(fmt.Sprintf("expected a *types.NotFoundException, got %v", err))
+		}
+	}
+
+	if _, err := awskms.NewAmazonKMSClient(ctx, awskms.Config{ChainID: 80001}, s.KMSClient()); !errors.Is(err, common2.ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig, got %v", err))
+	}
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	_, err = s.NewAmazonKMSClient(ctx, awskms.Config{KeyID: s.AddKey(p256Key), ChainID: 80001})
+	if !errors.Is(err, common2.ErrWrongKeySpec) {
+		panic(fmt.Sprintf("expected ErrWrongKeySpec, got %v", err))
+	}
+
+	c, err := s.NewAmazonKMSClient(ctx, awskms.Config{ChainID: 80001})
+	if err != nil {
+		panic(err)
+	}
+	tcs := map[string]error{
+		"ThrottlingException":         common2.ErrThrottled,
+		"AccessDeniedException":       common2.ErrPermissionDenied,
+		"DisabledException":           common2.ErrKeyDisabled,
+		"UnrecognizedClientException": common2.ErrPermissionDenied,
+	}
+	for errType, expected := range tcs {
+		s.SetError(errType)
+
+		_, err = c.SignHash(crypto.Keccak256Hash([]byte("Hello World")))
+		if !errors.Is(err, expected) {
+			panic(fmt.Sprintf("%v: expected %v, got %v", errType, expected, err))
+		}
+
+		_, err = c.GetEVMSignerFn()(c.GetAddress(), types.NewTx(&types.LegacyTx{To: &receiverAddr}))
+		if !errors.Is(err, expected) {
+			panic(fmt.Sprintf("%v: expected %v from the SignerFn, got %v", errType, expected, err))
+		}
+	}
+}
+
+func TestServer_KeyValidation(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	ctx := context.Background()
+	kmsClient := s.KMSClient()
+
+	tcs := []struct {
+		keySpec  kmstypes.KeySpec
+		keyUsage kmstypes.KeyUsageType
+		disable  bool
+		expected error
+	}{
+		{keySpec: kmstypes.KeySpecEccSecgP256k1, keyUsage: kmstypes.KeyUsageTypeSignVerify},
+		{keySpec: kmstypes.KeySpecEccNistP256, keyUsage: kmstypes.KeyUsageTypeSignVerify, expected: common2.ErrWrongKeySpec},
+		{keySpec: kmstypes.KeySpecEccSecgP256k1, keyUsage: kmstypes.KeyUsageTypeEncryptDecrypt, expected: common2.ErrWrongKeySpec},
+		{keySpec: kmstypes.KeySpecEccSecgP256k1, keyUsage: kmstypes.KeyUsageTypeSignVerify, disable: true, expected: common2.ErrKeyDisabled},
+	}
+
+	for i, tc := range tcs {
+		output, err := kmsClient.CreateKey(ctx, &kms.CreateKeyInput{KeySpec: tc.keySpec, KeyUsage: tc.keyUsage})
+		if err != nil {
+			panic(err)
+		}
+		keyID := *output.KeyMetadata.KeyId
+		if tc.disable {
+			if err = s.DisableKey(keyID); err != nil {
+				panic(err)
+			}
+		}
+
+		_, err = awskms.NewAmazonKMSClient(ctx, awskms.Config{KeyID: keyID, ChainID: 80001}, kmsClient)
+		if tc.expected == nil && err != nil {
+			panic(fmt.Sprintf("tc %v: %v", i, err))
+		}
+		if !errors.Is(err, tc.expected) {
+			panic(fmt.Sprintf("tc %v: expected %v, got %v", i, tc.expected, err))
+		}
+	}
+
+	// the key spec is still checked via GetPublicKey when the validation is skipped
+	output, err := kmsClient.CreateKey(ctx, &kms.CreateKeyInput{KeySpec: kmstypes.KeySpecEccNistP256, KeyUsage: kmstypes.KeyUsageTypeSignVerify})
+	if err != nil {
+		panic(err)
+	}
+	cfg := awskms.Config{KeyID: *output.KeyMetadata.KeyId, ChainID: 80001, SkipKeyValidation: true}
+	if _, err = awskms.NewAmazonKMSClient(ctx, cfg, kmsClient); !errors.Is(err, common2.ErrWrongKeySpec) {
+		panic(fmt.Sprintf("expected ErrWrongKeySpec, got %v", err))
+	}
+}
+
+func TestServer_ProvisionKey(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	ctx := context.Background()
+	kmsClient := s.KMSClient()
+	c, address, err := awskms.CreateKey(ctx, kmsClient, awskms.CreateKeyConfig{
+		Alias:       "customer-1",
+		Description: "signer of customer 1",
+		Tags:        map[string]string{"customer": "1"},
+		ChainID:     80001,
+	})
+	if err != nil {
+		panic(err)
+	}
+	if address != c.GetAddress() {
+		panic("invalid address")
+	}
+
+	// the key can be retrieved by its alias
+	aliasClient, err := awskms.NewAmazonKMSClient(ctx, awskms.Config{KeyID: "alias/customer-1", ChainID: 80001}, kmsClient)
+	if err != nil {
+		panic(err)
+	}
+	if aliasClient.GetAddress() != address {
+		panic("invalid address for the alias")
+	}
+
+	output, err := kmsClient.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(c.GetKeyID())})
+	if err != nil {
+		panic(err)
+	}
+	if *output.KeyMetadata.Description != "signer of customer 1" {
+		panic("invalid description")
+	}
+
+	// aliases are unique
+	_, _, err = awskms.CreateKey(ctx, kmsClient, awskms.CreateKeyConfig{Alias: "alias/customer-1", ChainID: 80001})
+	if !errors.Is(err, common2.ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig, got %v", err))
+	}
+
+	// the key of the failed alias is scheduled for deletion
+	listOutput, err := kmsClient.ListKeys(ctx, &kms.ListKeysInput{})
+	if err != nil {
+		panic(err)
+	}
+	if len(listOutput.Keys) != 2 {
+		panic(fmt.Sprintf("expected 2 keys, got %v", len(listOutput.Keys)))
+	}
+	for _, entry := range listOutput.Keys {
+		if aws.ToString(entry.KeyId) == c.GetKeyID() {
+			continue
+		}
+		output, err = kmsClient.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: entry.KeyId})
+		if err != nil {
+			panic(err)
+		}
+		if output.KeyMetadata.KeyState != kmstypes.KeyStatePendingDeletion {
+			panic(fmt.Sprintf("expected key state %v, got %v", kmstypes.KeyStatePendingDeletion, output.KeyMetadata.KeyState))
+		}
+	}
+
+	_, _, err = awskms.CreateKey(ctx, kmsClient, awskms.CreateKeyConfig{Alias: "alias/aws/kms"})
+	if !errors.Is(err, common2.ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig, got %v", err))
+	}
+}
+
+func TestServer_ListKeys(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	ctx := context.Background()
+	kmsClient := s.KMSClient()
+	c, address, err := awskms.CreateKey(ctx, kmsClient, awskms.CreateKeyConfig{
+		Alias:   "customer-1",
+		Tags:    map[string]string{"customer": "1"},
+		ChainID: 80001,
+	})
+	if err != nil {
+		panic(err)
+	}
+	disabledKeyID := s.NewKey()
+	if err = s.DisableKey(disabledKeyID); err != nil {
+		panic(err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	s.AddKey(p256Key)
+	// a key which cannot be described is skipped
+	if err = s.DenyKey(s.NewKey()); err != nil {
+		panic(err)
+	}
+
+	keys, err := c.ListKeys(ctx)
+	if err != nil {
+		panic(err)
+	}
+	if len(keys) != 2 {
+		panic(fmt.Sprintf("expected 2 keys, got %v", len(keys)))
+	}
+	if keys[0].KeyID != c.GetKeyID() || keys[0].Address != address {
+		panic(fmt.Sprintf("invalid key %v", keys[0]))
+	}
+	if len(keys[0].Aliases) != 1 || keys[0].Aliases[0] != "alias/customer-1" {
+		panic(fmt.Sprintf("invalid aliases %v", keys[0].Aliases))
+	}
+	if keys[0].Tags["customer"] != "1" {
+		panic(fmt.Sprintf("invalid tags %v", keys[0].Tags))
+	}
+	if keys[1].KeyID != disabledKeyID || keys[1].KeyState != string(kmstypes.KeyStateDisabled) ||
+		keys[1].Address != (common.Address{}) {
+		panic(fmt.Sprintf("invalid key %v", keys[1]))
+	}
+
+	keyInfo, err := awskms.FindKeyByAddress(ctx, kmsClient, address)
+	if err != nil {
+		panic(err)
+	}
+	if keyInfo.KeyID != c.GetKeyID() {
+		panic("invalid key found")
+	}
+
+	_, err = awskms.FindKeyByAddress(ctx, kmsClient, common.HexToAddress("0x1"))
+	if !errors.Is(err, common2.ErrKeyNotFound) {
+		panic(fmt.Sprintf("expected ErrKeyNotFound, got %v", err))
+	}
+}
@@ -3,7 +3,9 @@ package awskms
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"net/url"
+	"strings"
 )
 
 // Config represents required information to create an AWS KMS client.
@@ -15,6 +17,10 @@ type Config struct {
 	//
 	// See https://chainlist.org.
 	ChainID uint64 `json:"ChainID"`
+
+	// SkipKeyValidation skips the DescribeKey call made at construction to check the key spec, usage and state
+	// (e.g, if the credentials are not granted `kms:DescribeKey`). The key spec is still checked via GetPublicKey.
+	SkipKeyValidation bool `json:"SkipKeyValidation,omitempty"`
 }
 
 // IsValid checks if a Config is valid.
@@ -28,50 +34,197 @@ func (cfg Config) IsValid() (bool, error) {
 
 // StaticCredentialsConfig consists of AWS KMS Config with static credentials.
 //
+// It is an alias of CredentialsConfig kept for compatibility, whose credentials mode is forced to
+// CredentialsModeStatic by NewAmazonKMSClientWithStaticCredentials and LoadStaticCredentialsConfigConfigFromFile.
+//
 // Example:
 //   scConfig = StaticCredentialsConfig{
-//  	KeyID: "KEY_ID",
-// 		ChainID: 0,
+//  	Config: Config{KeyID: "KEY_ID", ChainID: 0},
 //		Region: "REGION_ID",
 //		AccessKeyID: "ACCESS_KEY_ID",
 //		SecretAccessKey: "SECRET_ACCESS_KEY",
 //		SessionToken: "SESSION_TOKEN",
 //  }
-type StaticCredentialsConfig struct {
+type StaticCredentialsConfig = CredentialsConfig
+
+const (
+	// CredentialsModeStatic uses the static AccessKeyID, SecretAccessKey and SessionToken of the config.
+	CredentialsModeStatic = "static"
+
+	// CredentialsModeDefault uses the SDK default credential chain (environment variables, shared config and
+	// credentials files, web identity token, ECS container credentials and EC2 instance metadata).
+	CredentialsModeDefault = "default"
+
+	// CredentialsModeProfile uses the named Profile of the shared config and credentials files.
+	CredentialsModeProfile = "profile"
+
+	// CredentialsModeWebIdentity exchanges a web identity token (e.g, an EKS service account token) for the
+	// credentials of a role via STS AssumeRoleWithWebIdentity.
+	CredentialsModeWebIdentity = "web-identity"
+)
+
+// AssumeRoleConfig consists of the information to assume a role via STS AssumeRole.
+type AssumeRoleConfig struct {
+	// RoleARN is the ARN of the role to assume.
+	RoleARN string `json:"RoleARN"`
+
+	// ExternalID is the external ID required by the trust policy of the role, if any.
+	ExternalID string `json:"ExternalID,omitempty"`
+
+	// SessionName is the name of the role session. If empty, a random name is generated by the SDK.
+	SessionName string `json:"SessionName,omitempty"`
+
+	// DurationSeconds is the duration of the role session. If zero, the SDK default (15 minutes) is used.
+	DurationSeconds int `json:"DurationSeconds,omitempty"`
+}
+
+// IsValid checks if an AssumeRoleConfig is valid.
+func (cfg AssumeRoleConfig) IsValid() (bool, error) {
+	if cfg.RoleARN == "" {
+		return false, fmt.Errorf("empty RoleARN")
+	}
+
+	if cfg.DurationSeconds < 0 {
+		return false, fmt.Errorf("invalid DurationSeconds %v", cfg.DurationSeconds)
+	}
+
+	return true, nil
+}
+
+// WebIdentityConfig consists of the information to assume a role with a web identity token.
+type WebIdentityConfig struct {
+	// RoleARN is the ARN of the role to assume.
+	// If empty, the environment variable `AWS_ROLE_ARN` is used.
+	RoleARN string `json:"RoleARN,omitempty"`
+
+	// TokenFile is the path of the file containing the web identity token.
+	// If empty, the environment variable `AWS_WEB_IDENTITY_TOKEN_FILE` is used.
+	TokenFile string `json:"TokenFile,omitempty"`
+
+	// SessionName is the name of the role session.
+	// If empty, the environment variable `AWS_ROLE_SESSION_NAME` is used, or a random name is generated by the SDK.
+	SessionName string `json:"SessionName,omitempty"`
+}
+
+// CredentialsConfig consists of AWS KMS Config with a configurable source of credentials.
+//
+// The source of credentials is selected by CredentialsMode. If CredentialsMode is empty, it is inferred as
+// CredentialsModeStatic if AccessKeyID is set, or CredentialsModeDefault otherwise; a static credentials config
+// file is thus a valid CredentialsConfig file. If AssumeRole is set, the resolved credentials are used to assume
+// the given role.
+//
+// Example (EKS with IRSA):
+//	cfg = CredentialsConfig{
+//		Config:          Config{KeyID: "KEY_ID", ChainID: 1},
+//		Region:          "REGION_ID",
+//		CredentialsMode: CredentialsModeWebIdentity,
+//	}
+type CredentialsConfig struct {
 	Config
 
 	// Region is the region of the AWS KMS Key.
-	Region string `json:"Region"`
+	// If empty, the region is resolved from the environment or the shared config file.
+	Region string `json:"Region,omitempty"`
 
-	// AccessKeyID is the access key ID of the given account for the sake of connecting to the remote AWS client.
-	AccessKeyID string `json:"AccessKeyID"`
+	// CredentialsMode is the source of credentials: "static", "default", "profile" or "web-identity".
+	CredentialsMode string `json:"CredentialsMode,omitempty"`
+
+	// AccessKeyID is the access key ID used by the static mode.
+	AccessKeyID string `json:"AccessKeyID,omitempty"`
 
 	// SecretAccessKey is the secret key for the AccessKeyID.
-	SecretAccessKey string `json:"SecretAccessKey"`
+	SecretAccessKey string `json:"SecretAccessKey,omitempty"`
 
 	// SessionToken is the session ID.
 	SessionToken string `json:"SessionToken,omitempty"`
+
+	// Profile is the name of the shared config profile used by the profile mode.
+	Profile string `json:"Profile,omitempty"`
+
+	// WebIdentity is the configuration of the web-identity mode. If nil, it is resolved from the environment.
+	WebIdentity *WebIdentityConfig `json:"WebIdentity,omitempty"`
+
+	// AssumeRole is the role to assume with the resolved credentials, if any.
+	AssumeRole *AssumeRoleConfig `json:"AssumeRole,omitempty"`
+
+	// Endpoint is the URL of the KMS endpoint (e.g, a VPC endpoint, a FIPS endpoint or LocalStack).
+	// If empty, the public endpoint of the Region is used. Note that STS calls still use the public endpoint.
+	Endpoint string `json:"Endpoint,omitempty"`
 }
 
-func (cfg StaticCredentialsConfig) IsValid() (bool, error) {
-	if cfg.Region == "" {
-		return false, fmt.Errorf("empty Region")
+// GetCredentialsMode returns the credentials mode of the config, inferring it if CredentialsMode is empty.
+func (cfg CredentialsConfig) GetCredentialsMode() string {
+	if cfg.CredentialsMode != "" {
+		return strings.ToLower(cfg.CredentialsMode)
 	}
 
-	if cfg.AccessKeyID == "" {
-		return false, fmt.Errorf("empty AccessKeyID")
+	if cfg.AccessKeyID != "" {
+		return CredentialsModeStatic
 	}
 
-	if cfg.SecretAccessKey == "" {
-		return false, fmt.Errorf("empty SecretAccessKey")
+	return CredentialsModeDefault
+}
+
+// IsValid checks if a CredentialsConfig is valid.
+func (cfg CredentialsConfig) IsValid() (bool, error) {
+	switch cfg.GetCredentialsMode() {
+	case CredentialsModeStatic:
+		if cfg.Region == "" {
+			return false, fmt.Errorf("empty Region")
+		}
+
+		if cfg.AccessKeyID == "" {
+			return false, fmt.Errorf("empty AccessKeyID")
+		}
+
+		if cfg.SecretAccessKey == "" {
+			return false, fmt.Errorf("empty SecretAccessKey")
+		}
+	case CredentialsModeDefault:
+	case CredentialsModeProfile:
+		if cfg.Profile == "" {
+			return false, fmt.Errorf("empty Profile")
+		}
+	case CredentialsModeWebIdentity:
+	default:
+		return false, fmt.Errorf("CredentialsMode `%v` not supported", cfg.CredentialsMode)
+	}
+
+	if cfg.GetCredentialsMode() != CredentialsModeStatic && (cfg.AccessKeyID != "" || cfg.SecretAccessKey != "") {
+		return false, fmt.Errorf("static credentials are not allowed with CredentialsMode `%v`", cfg.CredentialsMode)
+	}
+
+	if cfg.AssumeRole != nil {
+		if _, err := cfg.AssumeRole.IsValid(); err != nil {
+			return false, fmt.Errorf("invalid AssumeRole: %v", err)
+		}
+	}
+
+	if err := validateEndpoint(cfg.Endpoint); err != nil {
+		return false, err
 	}
 
 	return cfg.Config.IsValid()
 }
 
-// LoadConfigFromFile loads the config from the given config file.
+// validateEndpoint checks if the given endpoint, if any, is an absolute URL.
+func validateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid Endpoint %v", endpoint)
+	}
+
+	return nil
+}
+
+// LoadConfigFromFile loads the config from the given config file, resolving the `${ENV_VAR}` and `file://path`
+// references of its string values.
 func LoadConfigFromFile(filePath string) (*Config, error) {
-	f, err := ioutil.ReadFile(filePath)
+	f, err := common2.ReadConfigFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -90,8 +243,10 @@ func LoadConfigFromFile(filePath string) (*Config, error) {
 }
 
 // LoadStaticCredentialsConfigConfigFromFile loads a static credential config from the given config file.
+// String values can reference environment variables (`${ENV_VAR}`) or secret files (`file://path`), e.g.
+// `"SecretAccessKey": "${AWS_SECRET_ACCESS_KEY}"`; see common.InterpolateJSON.
 func LoadStaticCredentialsConfigConfigFromFile(filePath string) (*StaticCredentialsConfig, error) {
-	f, err := ioutil.ReadFile(filePath)
+	f, err := common2.ReadConfigFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -101,6 +256,28 @@ func LoadStaticCredentialsConfigConfigFromFile(filePath string) (*StaticCredenti
 	if err != nil {
 		return nil, err
 	}
+	cfg.CredentialsMode = CredentialsModeStatic
+
+	if _, err = cfg.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// LoadCredentialsConfigFromFile loads a credentials config from the given config file, resolving the `${ENV_VAR}`
+// and `file://path` references of its string values.
+func LoadCredentialsConfigFromFile(filePath string) (*CredentialsConfig, error) {
+	f, err := common2.ReadConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg CredentialsConfig
+	err = json.Unmarshal(f, &cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	if _, err = cfg.IsValid(); err != nil {
 		return nil, err
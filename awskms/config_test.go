@@ -1,8 +1,15 @@
 package awskms
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -17,7 +24,7 @@ func TestLoadConfigFromFile(t *testing.T) {
 }
 
 func TestLoadStaticCredentialsConfigConfigFromFile(t *testing.T) {
-	filePath := "./static-credentials-config-example.json"
+	filePath := "./config-static-credentials-example.json"
 	cfg, err := LoadStaticCredentialsConfigConfigFromFile(filePath)
 	if err != nil {
 		panic(err)
@@ -25,3 +32,154 @@ func TestLoadStaticCredentialsConfigConfigFromFile(t *testing.T) {
 	jsb, _ := json.MarshalIndent(cfg, "", "\t")
 	fmt.Println(string(jsb))
 }
+
+func TestNewAmazonKMSClientWithStaticCredentials(t *testing.T) {
+	// the static credentials are required, even without CredentialsMode
+	_, err := NewAmazonKMSClientWithStaticCredentials(context.Background(), StaticCredentialsConfig{
+		Config: Config{KeyID: "KEY_ID", ChainID: 1},
+		Region: "us-west-1",
+	})
+	if !errors.Is(err, common2.ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected %v, got %v", common2.ErrInvalidConfig, err))
+	}
+}
+
+func TestLoadCredentialsConfigFromFile(t *testing.T) {
+	for _, filePath := range []string{"./config-web-identity-example.json", "./config-static-credentials-example.json"} {
+		cfg, err := LoadCredentialsConfigFromFile(filePath)
+		if err != nil {
+			panic(err)
+		}
+		jsb, _ := json.MarshalIndent(cfg, "", "\t")
+		fmt.Println(string(jsb))
+	}
+}
+
+func TestCredentialsConfig_IsValid(t *testing.T) {
+	tcs := []struct {
+		cfg     CredentialsConfig
+		mode    string
+		isValid bool
+	}{
+		{cfg: CredentialsConfig{Region: "us-west-1"}, mode: CredentialsModeDefault, isValid: false},
+		{cfg: CredentialsConfig{Config: Config{KeyID: "KEY_ID"}}, mode: CredentialsModeDefault, isValid: true},
+		{cfg: CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, Region: "us-west-1", AccessKeyID: "A", SecretAccessKey: "S"}, mode: CredentialsModeStatic, isValid: true},
+		{cfg: CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, AccessKeyID: "A", SecretAccessKey: "S"}, mode: CredentialsModeStatic, isValid: false},
+		{cfg: CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, CredentialsMode: "Profile"}, mode: CredentialsModeProfile, isValid: false},
+		{cfg: CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, CredentialsMode: "profile", Profile: "prod"}, mode: CredentialsModeProfile, isValid: true},
+		{cfg: CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, CredentialsMode: "profile", Profile: "prod", AccessKeyID: "A"}, mode: CredentialsModeProfile, isValid: false},
+		{cfg: CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, CredentialsMode: "web-identity"}, mode: CredentialsModeWebIdentity, isValid: true},
+		{cfg: CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, CredentialsMode: "sso"}, mode: "sso", isValid: false},
+		{cfg: CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, AssumeRole: &AssumeRoleConfig{}}, mode: CredentialsModeDefault, isValid: false},
+		{cfg: CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, AssumeRole: &AssumeRoleConfig{RoleARN: "ROLE_ARN"}}, mode: CredentialsModeDefault, isValid: true},
+	}
+
+	for i, tc := range tcs {
+		if mode := tc.cfg.GetCredentialsMode(); mode != tc.mode {
+			panic(fmt.Sprintf("tc %v: expected mode %v, got %v", i, tc.mode, mode))
+		}
+		isValid, err := tc.cfg.IsValid()
+		if isValid != tc.isValid {
+			panic(fmt.Sprintf("tc %v: expected isValid = %v, got %v (%v)", i, tc.isValid, isValid, err))
+		}
+	}
+}
+
+func TestCredentialsConfig_LoadAWSConfig(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "awskms")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	credentialsFile := filepath.Join(dir, "credentials")
+	err = ioutil.WriteFile(credentialsFile, []byte("[prod]\naws_access_key_id = PROFILE_KEY\naws_secret_access_key = PROFILE_SECRET\n"), 0600)
+	if err != nil {
+		panic(err)
+	}
+	for key, value := range map[string]string{
+		"AWS_SHARED_CREDENTIALS_FILE": credentialsFile,
+		"AWS_CONFIG_FILE":             filepath.Join(dir, "config"),
+		"AWS_ACCESS_KEY_ID":           "ENV_KEY",
+		"AWS_SECRET_ACCESS_KEY":       "ENV_SECRET",
+		"AWS_REGION":                  "us-west-1",
+	} {
+		oldValue, ok := os.LookupEnv(key)
+		_ = os.Setenv(key, value)
+		if ok {
+			defer os.Setenv(key, oldValue)
+		} else {
+			defer os.Unsetenv(key)
+		}
+	}
+
+	tcs := []struct {
+		cfg         CredentialsConfig
+		region      string
+		accessKeyID string
+	}{
+		{
+			cfg:         CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, Region: "eu-west-1", AccessKeyID: "STATIC_KEY", SecretAccessKey: "STATIC_SECRET"},
+			region:      "eu-west-1",
+			accessKeyID: "STATIC_KEY",
+		},
+		{
+			cfg:         CredentialsConfig{Config: Config{KeyID: "KEY_ID"}},
+			region:      "us-west-1",
+			accessKeyID: "ENV_KEY",
+		},
+		{
+			cfg:         CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, CredentialsMode: CredentialsModeProfile, Profile: "prod"},
+			region:      "us-west-1",
+			accessKeyID: "PROFILE_KEY",
+		},
+	}
+
+	for i, tc := range tcs {
+		awsCfg, err := tc.cfg.LoadAWSConfig(ctx)
+		if err != nil {
+			panic(fmt.Sprintf("tc %v: %v", i, err))
+		}
+		if awsCfg.Region != tc.region {
+			panic(fmt.Sprintf("tc %v: expected region %v, got %v", i, tc.region, awsCfg.Region))
+		}
+
+		creds, err := awsCfg.Credentials.Retrieve(ctx)
+		if err != nil {
+			panic(fmt.Sprintf("tc %v: %v", i, err))
+		}
+		if creds.AccessKeyID != tc.accessKeyID {
+			panic(fmt.Sprintf("tc %v: expected access key ID %v, got %v", i, tc.accessKeyID, creds.AccessKeyID))
+		}
+	}
+
+	// a malformed shared config file is an invalid config
+	if err = ioutil.WriteFile(filepath.Join(dir, "config"), []byte("[profile broken\n"), 0600); err != nil {
+		panic(err)
+	}
+	_, err = CredentialsConfig{Config: Config{KeyID: "KEY_ID"}}.LoadAWSConfig(ctx)
+	_ = os.Remove(filepath.Join(dir, "config"))
+	if !errors.Is(err, common2.ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected %v, got %v", common2.ErrInvalidConfig, err))
+	}
+
+	// the web-identity mode requires a role and a token file
+	_, err = CredentialsConfig{Config: Config{KeyID: "KEY_ID"}, CredentialsMode: CredentialsModeWebIdentity}.LoadAWSConfig(ctx)
+	if err == nil {
+		panic("expected an error without a web identity role")
+	}
+
+	awsCfg, err := CredentialsConfig{
+		Config:          Config{KeyID: "KEY_ID"},
+		CredentialsMode: CredentialsModeWebIdentity,
+		WebIdentity:     &WebIdentityConfig{RoleARN: "ROLE_ARN", TokenFile: filepath.Join(dir, "token")},
+		AssumeRole:      &AssumeRoleConfig{RoleARN: "OTHER_ROLE_ARN", ExternalID: "EXTERNAL_ID"},
+	}.LoadAWSConfig(ctx)
+	if err != nil {
+		panic(err)
+	}
+	if _, ok := awsCfg.Credentials.(*aws.CredentialsCache); !ok {
+		panic(fmt.Sprintf("expected an *aws.CredentialsCache, got %T", awsCfg.Credentials))
+	}
+}
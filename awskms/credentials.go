@@ -0,0 +1,100 @@
+package awskms
+
+import (
+	"context"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"os"
+	"time"
+)
+
+// LoadAWSConfig resolves the AWS region and credentials specified by the CredentialsConfig.
+// The returned aws.Config can be used to create any AWS service client, e.g. kms.NewFromConfig.
+func (cfg CredentialsConfig) LoadAWSConfig(ctx context.Context) (aws.Config, error) {
+	if _, err := cfg.IsValid(); err != nil {
+		return aws.Config{}, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+
+	options := make([]func(*config.LoadOptions) error, 0)
+	if cfg.Region != "" {
+		options = append(options, config.WithRegion(cfg.Region))
+	}
+	switch cfg.GetCredentialsMode() {
+	case CredentialsModeStatic:
+		options = append(options, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)))
+	case CredentialsModeProfile:
+		options = append(options, config.WithSharedConfigProfile(cfg.Profile))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return aws.Config{}, common2.NewKMSError("LoadConfig", common2.ErrInvalidConfig, fmt.Errorf("cannot load AWS config: %w", err))
+	}
+	if awsCfg.Region == "" {
+		return aws.Config{}, fmt.Errorf("%w: cannot resolve AWS region", common2.ErrInvalidConfig)
+	}
+
+	if cfg.GetCredentialsMode() == CredentialsModeWebIdentity {
+		provider, err := cfg.webIdentityProvider(awsCfg)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		awsCfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	if cfg.AssumeRole != nil {
+		assumeRole := *cfg.AssumeRole
+		awsCfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsCfg),
+			assumeRole.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+				if assumeRole.ExternalID != "" {
+					o.ExternalID = aws.String(assumeRole.ExternalID)
+				}
+				if assumeRole.SessionName != "" {
+					o.RoleSessionName = assumeRole.SessionName
+				}
+				if assumeRole.DurationSeconds > 0 {
+					o.Duration = time.Duration(assumeRole.DurationSeconds) * time.Second
+				}
+			}))
+	}
+
+	return awsCfg, nil
+}
+
+// webIdentityProvider returns the credentials provider of the web-identity mode, falling back to the environment
+// variables set by EKS for the fields left empty.
+func (cfg CredentialsConfig) webIdentityProvider(awsCfg aws.Config) (aws.CredentialsProvider, error) {
+	var webIdentity WebIdentityConfig
+	if cfg.WebIdentity != nil {
+		webIdentity = *cfg.WebIdentity
+	}
+	if webIdentity.RoleARN == "" {
+		webIdentity.RoleARN = os.Getenv("AWS_ROLE_ARN")
+	}
+	if webIdentity.TokenFile == "" {
+		webIdentity.TokenFile = os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	}
+	if webIdentity.SessionName == "" {
+		webIdentity.SessionName = os.Getenv("AWS_ROLE_SESSION_NAME")
+	}
+
+	if webIdentity.RoleARN == "" {
+		return nil, fmt.Errorf("%w: empty WebIdentity.RoleARN and AWS_ROLE_ARN", common2.ErrInvalidConfig)
+	}
+	if webIdentity.TokenFile == "" {
+		return nil, fmt.Errorf("%w: empty WebIdentity.TokenFile and AWS_WEB_IDENTITY_TOKEN_FILE", common2.ErrInvalidConfig)
+	}
+
+	return stscreds.NewWebIdentityRoleProvider(sts.NewFromConfig(awsCfg), webIdentity.RoleARN,
+		stscreds.IdentityTokenFile(webIdentity.TokenFile), func(o *stscreds.WebIdentityRoleOptions) {
+			if webIdentity.SessionName != "" {
+				o.RoleSessionName = webIdentity.SessionName
+			}
+		}), nil
+}
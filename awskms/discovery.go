@@ -0,0 +1,182 @@
+package awskms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"sort"
+)
+
+// KeyInfo describes a secp256k1 signing key of the AWS KMS.
+type KeyInfo struct {
+	// KeyID is the ID of the key.
+	KeyID string `json:"KeyID"`
+
+	// ARN is the ARN of the key.
+	ARN string `json:"ARN"`
+
+	// Aliases are the aliases of the key (with the "alias/" prefix).
+	Aliases []string `json:"Aliases,omitempty"`
+
+	// Tags are the tags of the key.
+	Tags map[string]string `json:"Tags,omitempty"`
+
+	// Description is the description of the key.
+	Description string `json:"Description,omitempty"`
+
+	// KeyState is the state of the key (e.g, "Enabled", "Disabled").
+	KeyState string `json:"KeyState"`
+
+	// Address is the EVM address derived from the public key. It is only set for enabled keys, as the public key of
+	// a disabled key cannot be retrieved.
+	Address common.Address `json:"Address"`
+}
+
+// ListKeys lists all the ECC_SECG_P256K1 signing keys of the account and region of the given kms.Client, together
+// with their aliases, tags and EVM addresses. Keys pending deletion are skipped, as well as the keys which cannot be
+// described because access is denied (e.g, keys of other accounts) or they have been deleted meanwhile.
+//
+// It requires the `kms:ListKeys`, `kms:ListAliases`, `kms:DescribeKey`, `kms:ListResourceTags` and
+// `kms:GetPublicKey` permissions.
+func ListKeys(ctx context.Context, kmsClient *kms.Client) ([]KeyInfo, error) {
+	aliases, err := listAliases(ctx, kmsClient)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]KeyInfo, 0)
+	keysPaginator := kms.NewListKeysPaginator(kmsClient, &kms.ListKeysInput{})
+	for keysPaginator.HasMorePages() {
+		page, err := keysPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, wrapError("ListKeys", err)
+		}
+
+		for _, entry := range page.Keys {
+			keyInfo, err := describeKey(ctx, kmsClient, aws.ToString(entry.KeyId))
+			if errors.Is(err, common2.ErrPermissionDenied) || errors.Is(err, common2.ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if keyInfo == nil {
+				continue
+			}
+			keyInfo.Aliases = aliases[keyInfo.KeyID]
+
+			res = append(res, *keyInfo)
+		}
+	}
+
+	return res, nil
+}
+
+// FindKeyByAddress returns the ECC_SECG_P256K1 signing key of the account and region of the given kms.Client, whose
+// EVM address is the given address. If no such key exists, an error matching common.ErrKeyNotFound is returned.
+func FindKeyByAddress(ctx context.Context, kmsClient *kms.Client, address common.Address) (*KeyInfo, error) {
+	keys, err := ListKeys(ctx, kmsClient)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, keyInfo := range keys {
+		if keyInfo.Address == address {
+			return &keyInfo, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no key with address %v", common2.ErrKeyNotFound, address)
+}
+
+// ListKeys lists all the ECC_SECG_P256K1 signing keys of the account and region of the AmazonKMSClient.
+// See ListKeys for more detail.
+func (c AmazonKMSClient) ListKeys(ctx context.Context) ([]KeyInfo, error) {
+	return ListKeys(ctx, c.kmsClient)
+}
+
+// describeKey returns the KeyInfo of the given key, or nil if the key is not a secp256k1 signing key, is pending
+// deletion, or has no metadata.
+func describeKey(ctx context.Context, kmsClient *kms.Client, keyID string) (*KeyInfo, error) {
+	output, err := kmsClient.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, wrapError("DescribeKey", err)
+	}
+	metadata := output.KeyMetadata
+	if metadata == nil {
+		return nil, nil
+	}
+
+	err = validateKeyMetadata(metadata)
+	switch {
+	case metadata.KeyState == kmstypes.KeyStatePendingDeletion || metadata.KeyState == kmstypes.KeyStatePendingReplicaDeletion:
+		return nil, nil
+	case err != nil && !errors.Is(err, common2.ErrKeyDisabled):
+		return nil, nil
+	}
+
+	keyInfo := &KeyInfo{
+		KeyID:       aws.ToString(metadata.KeyId),
+		ARN:         aws.ToString(metadata.Arn),
+		Description: aws.ToString(metadata.Description),
+		KeyState:    string(metadata.KeyState),
+		Tags:        make(map[string]string),
+	}
+
+	tagsPaginator := kms.NewListResourceTagsPaginator(kmsClient, &kms.ListResourceTagsInput{KeyId: metadata.KeyId})
+	for tagsPaginator.HasMorePages() {
+		page, err := tagsPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, wrapError("ListResourceTags", err)
+		}
+		for _, tag := range page.Tags {
+			keyInfo.Tags[aws.ToString(tag.TagKey)] = aws.ToString(tag.TagValue)
+		}
+	}
+
+	if metadata.KeyState == kmstypes.KeyStateEnabled {
+		pubKeyOutput, err := kmsClient.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: metadata.KeyId})
+		if err != nil {
+			return nil, wrapError("GetPublicKey", err)
+		}
+		pubKey, err := parseKMSPublicKey(pubKeyOutput)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key of key %v: %w", keyInfo.KeyID, err)
+		}
+		keyInfo.Address = crypto.PubkeyToAddress(*pubKey)
+	}
+
+	return keyInfo, nil
+}
+
+// listAliases returns the aliases of the account and region of the given kms.Client, indexed by key ID.
+func listAliases(ctx context.Context, kmsClient *kms.Client) (map[string][]string, error) {
+	res := make(map[string][]string)
+
+	paginator := kms.NewListAliasesPaginator(kmsClient, &kms.ListAliasesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, wrapError("ListAliases", err)
+		}
+
+		for _, alias := range page.Aliases {
+			if alias.TargetKeyId == nil {
+				continue
+			}
+			res[*alias.TargetKeyId] = append(res[*alias.TargetKeyId], aws.ToString(alias.AliasName))
+		}
+	}
+
+	for _, keyAliases := range res {
+		sort.Strings(keyAliases)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,43 @@
+package awskms
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListKeys_EmptyMetadata(t *testing.T) {
+	// a server describing its only key without KeyMetadata
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.ListAliases":
+			_, _ = w.Write([]byte(`{"Aliases": []}`))
+		case "TrentService.ListKeys":
+			_, _ = w.Write([]byte(`{"Keys": [{"KeyId": "KEY_ID"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	kmsClient := kms.New(kms.Options{
+		Region:           "us-west-1",
+		Credentials:      credentials.NewStaticCredentialsProvider("ACCESS_KEY_ID", "SECRET_ACCESS_KEY", ""),
+		EndpointResolver: kms.EndpointResolverFromURL(server.URL),
+		Retryer:          aws.NopRetryer{},
+	})
+
+	keys, err := ListKeys(context.Background(), kmsClient)
+	if err != nil {
+		panic(err)
+	}
+	if len(keys) != 0 {
+		panic(fmt.Sprintf("expected no keys, got %v", keys))
+	}
+}
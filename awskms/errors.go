@@ -0,0 +1,52 @@
+package awskms
+
+import (
+	"errors"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/smithy-go"
+)
+
+// wrapError wraps an error returned by the AWS SDK into a common2.KMSError, whose Kind is derived from the AWS
+// error code.
+func wrapError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return common2.NewKMSError(op, errorKind(err), err)
+}
+
+// errorKind maps an error returned by the AWS SDK to one of the sentinel errors of the common package.
+func errorKind(err error) error {
+	// the retry quota of the client has been exhausted by previous failures
+	var quotaErr ratelimit.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		return common2.ErrThrottled
+	}
+
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+
+	switch apiErr.ErrorCode() {
+	case "NotFoundException":
+		return common2.ErrKeyNotFound
+	case "DisabledException", "KMSInvalidStateException", "KeyUnavailableException":
+		return common2.ErrKeyDisabled
+	case "InvalidKeyUsageException", "UnsupportedOperationException":
+		return common2.ErrWrongKeySpec
+	case "KMSInvalidSignatureException":
+		return common2.ErrSignatureInvalid
+	case "ThrottlingException", "ThrottledException", "LimitExceededException", "RequestLimitExceeded":
+		return common2.ErrThrottled
+	case "AccessDeniedException", "UnrecognizedClientException", "InvalidSignatureException",
+		"IncompleteSignature", "ExpiredTokenException", "InvalidClientTokenId", "MissingAuthenticationToken":
+		return common2.ErrPermissionDenied
+	case "ValidationException", "InvalidArnException", "InvalidKeyIdException", "AlreadyExistsException":
+		return common2.ErrInvalidConfig
+	}
+
+	return nil
+}
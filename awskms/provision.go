@@ -0,0 +1,132 @@
+package awskms
+
+import (
+	"context"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"sort"
+	"strings"
+)
+
+const (
+	aliasPrefix = "alias/"
+
+	// minPendingWindowInDays is the minimum waiting period, in days, before the AWS KMS deletes a key.
+	minPendingWindowInDays = 7
+)
+
+// CreateKeyConfig consists of the information to create a new EVM signing key on the AWS KMS.
+type CreateKeyConfig struct {
+	// Alias is the alias of the key, with or without the "alias/" prefix. It is optional.
+	Alias string `json:"Alias,omitempty"`
+
+	// Description is the description of the key.
+	Description string `json:"Description,omitempty"`
+
+	// Tags are the tags of the key.
+	Tags map[string]string `json:"Tags,omitempty"`
+
+	// ChainID is the ID of the target EVM chain.
+	//
+	// See https://chainlist.org.
+	ChainID uint64 `json:"ChainID"`
+}
+
+// IsValid checks if a CreateKeyConfig is valid.
+func (cfg CreateKeyConfig) IsValid() (bool, error) {
+	if cfg.Alias != "" && strings.TrimPrefix(cfg.Alias, aliasPrefix) == "" {
+		return false, fmt.Errorf("empty Alias name")
+	}
+
+	if strings.HasPrefix(cfg.Alias, aliasPrefix+"aws/") {
+		return false, fmt.Errorf("the Alias prefix `alias/aws/` is reserved for AWS managed keys")
+	}
+
+	return true, nil
+}
+
+// CreateKey creates a new ECC_SECG_P256K1 signing key on the AWS KMS with the given alias and tags, waits for it to
+// be enabled, and returns an AmazonKMSClient for the new key together with its EVM address.
+//
+// The ID of the new key can be retrieved via GetKeyID. If the alias cannot be created, the deletion of the new key is
+// scheduled with the minimum waiting period of 7 days, so that no orphaned key is left behind.
+func CreateKey(ctx context.Context, kmsClient *kms.Client, cfg CreateKeyConfig, txSigner ...types.Signer) (*AmazonKMSClient, common.Address, error) {
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+
+	input := &kms.CreateKeyInput{
+		KeySpec:  kmstypes.KeySpecEccSecgP256k1,
+		KeyUsage: kmstypes.KeyUsageTypeSignVerify,
+	}
+	if cfg.Description != "" {
+		input.Description = aws.String(cfg.Description)
+	}
+	tagKeys := make([]string, 0, len(cfg.Tags))
+	for key := range cfg.Tags {
+		tagKeys = append(tagKeys, key)
+	}
+	sort.Strings(tagKeys)
+	for _, key := range tagKeys {
+		input.Tags = append(input.Tags, kmstypes.Tag{TagKey: aws.String(key), TagValue: aws.String(cfg.Tags[key])})
+	}
+
+	output, err := kmsClient.CreateKey(ctx, input)
+	if err != nil {
+		return nil, common.Address{}, wrapError("CreateKey", err)
+	}
+	keyID := aws.ToString(output.KeyMetadata.KeyId)
+
+	if cfg.Alias != "" {
+		alias := cfg.Alias
+		if !strings.HasPrefix(alias, aliasPrefix) {
+			alias = aliasPrefix + alias
+		}
+		_, err = kmsClient.CreateAlias(ctx, &kms.CreateAliasInput{
+			AliasName:   aws.String(alias),
+			TargetKeyId: aws.String(keyID),
+		})
+		if err != nil {
+			err = wrapError("CreateAlias", fmt.Errorf("cannot create alias %v for key %v: %w", alias, keyID, err))
+			_, delErr := kmsClient.ScheduleKeyDeletion(ctx, &kms.ScheduleKeyDeletionInput{
+				KeyId:               aws.String(keyID),
+				PendingWindowInDays: aws.Int32(minPendingWindowInDays),
+			})
+			if delErr != nil {
+				return nil, common.Address{}, fmt.Errorf("%w (cannot schedule the deletion of key %v: %v)", err, keyID, delErr)
+			}
+			return nil, common.Address{}, err
+		}
+	}
+
+	err = common2.PollUntil(ctx, func(ctx context.Context) (bool, error) {
+		output, err := kmsClient.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(keyID)})
+		if err != nil {
+			return false, wrapError("DescribeKey", err)
+		}
+
+		switch output.KeyMetadata.KeyState {
+		case kmstypes.KeyStateEnabled:
+			return true, nil
+		case kmstypes.KeyStateCreating:
+			return false, nil
+		default:
+			return false, fmt.Errorf("%w: key %v is in state %v", common2.ErrKeyDisabled, keyID, output.KeyMetadata.KeyState)
+		}
+	})
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("key %v is not enabled: %w", keyID, err)
+	}
+
+	c, err := NewAmazonKMSClient(ctx, Config{KeyID: keyID, ChainID: cfg.ChainID}, kmsClient, txSigner...)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+
+	return c, c.GetAddress(), nil
+}
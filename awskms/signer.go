@@ -7,13 +7,13 @@ import (
 	"fmt"
 	common2 "github.com/LampardNguyen234/evm-kms/common"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
 	"github.com/pkg/errors"
 	"math/big"
 )
@@ -65,16 +65,19 @@ type AmazonKMSClient struct {
 //	}
 func NewAmazonKMSClient(ctx context.Context, cfg Config, kmsClient *kms.Client, txSigner ...types.Signer) (*AmazonKMSClient, error) {
 	if _, err := cfg.IsValid(); err != nil {
-		return nil, fmt.Errorf("invalid config")
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
 	}
 
-	signer := types.NewLondonSigner(new(big.Int).SetUint64(cfg.ChainID))
-	if len(txSigner) > 0 {
-		signer = txSigner[0]
-	}
+	signer := common2.NewTxSigner(cfg.ChainID, txSigner...)
 
 	c := &AmazonKMSClient{kmsClient: kmsClient, ctx: ctx, cfg: cfg, signer: signer}
 
+	if !cfg.SkipKeyValidation {
+		if err := c.validateKey(); err != nil {
+			return nil, err
+		}
+	}
+
 	pubKey, err := c.getPublicKey()
 	if err != nil {
 		return nil, err
@@ -85,34 +88,33 @@ func NewAmazonKMSClient(ctx context.Context, cfg Config, kmsClient *kms.Client,
 }
 
 // NewAmazonKMSClientWithStaticCredentials is an alternative of NewAmazonKMSClient but uses a StaticCredentialsConfig.
+// The static credentials of the config are used, regardless of its CredentialsMode.
 func NewAmazonKMSClientWithStaticCredentials(ctx context.Context, cfg StaticCredentialsConfig, txSigner ...types.Signer) (*AmazonKMSClient, error) {
-	if _, err := cfg.IsValid(); err != nil {
-		return nil, fmt.Errorf("invalid config")
-	}
+	cfg.CredentialsMode = CredentialsModeStatic
 
-	signer := types.NewLondonSigner(new(big.Int).SetUint64(cfg.ChainID))
-	if len(txSigner) > 0 {
-		signer = txSigner[0]
-	}
+	return NewAmazonKMSClientWithCredentials(ctx, cfg, txSigner...)
+}
 
-	awsCfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(cfg.Region),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-			cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)))
+// NewAmazonKMSClientWithCredentials is an alternative of NewAmazonKMSClient but uses a CredentialsConfig, which
+// supports the SDK default credential chain, shared config profiles, web identity tokens and STS AssumeRole.
+func NewAmazonKMSClientWithCredentials(ctx context.Context, cfg CredentialsConfig, txSigner ...types.Signer) (*AmazonKMSClient, error) {
+	awsCfg, err := cfg.LoadAWSConfig(ctx)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	kmsClient := kms.NewFromConfig(awsCfg)
 
-	c := &AmazonKMSClient{kmsClient: kmsClient, ctx: ctx, cfg: cfg.Config, signer: signer}
+	kmsClient := kms.NewFromConfig(awsCfg, func(o *kms.Options) {
+		if cfg.Endpoint != "" {
+			o.EndpointResolver = kms.EndpointResolverFromURL(cfg.Endpoint)
+		}
+	})
 
-	pubKey, err := c.getPublicKey()
-	if err != nil {
-		return nil, err
-	}
-	c.publicKey = pubKey
+	return NewAmazonKMSClient(ctx, cfg.Config, kmsClient, txSigner...)
+}
 
-	return c, nil
+// GetKeyID returns the ID of the AWS KMS key used by the AmazonKMSClient.
+func (c AmazonKMSClient) GetKeyID() string {
+	return c.cfg.KeyID
 }
 
 // GetAddress returns the EVM address of the current signer.
@@ -129,6 +131,11 @@ func (c AmazonKMSClient) GetPublicKey() (*ecdsa.PublicKey, error) {
 // Although the AWS KMS does not support keccak256 hash function (it uses SHA256 instead), it will not care about
 // which hash function to use if you send the hash of message to the KMS.
 func (c AmazonKMSClient) SignHash(digest common.Hash) ([]byte, error) {
+	return c.SignHashWithContext(c.ctx, digest)
+}
+
+// SignHashWithContext is the same as SignHash, but the remote call is bound to the given context.
+func (c AmazonKMSClient) SignHashWithContext(ctx context.Context, digest common.Hash) ([]byte, error) {
 	signInput := &kms.SignInput{
 		KeyId:            &c.cfg.KeyID,
 		Message:          digest[:],
@@ -136,61 +143,61 @@ func (c AmazonKMSClient) SignHash(digest common.Hash) ([]byte, error) {
 		MessageType:      signingMessageType,
 	}
 
-	result, err := c.kmsClient.Sign(c.ctx, signInput)
+	result, err := c.kmsClient.Sign(ctx, signInput)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign digest: %v", err)
+		return nil, wrapError("Sign", err)
 	}
 
 	return c.parseKMSSignature(digest, result.Signature)
 }
 
+// SignTypedData calls the remote AWS KMS to sign the EIP-712 digest of the given typed data.
+// If legacyV is set to true, the returned v will be either 27 or 28 (as returned by `eth_signTypedData_v4`).
+func (c AmazonKMSClient) SignTypedData(typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return c.SignTypedDataWithContext(c.ctx, typedData, legacyV)
+}
+
+// SignTypedDataWithContext is the same as SignTypedData, but the remote call is bound to the given context.
+func (c AmazonKMSClient) SignTypedDataWithContext(ctx context.Context, typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return common2.SignTypedData(ctx, c.SignHashWithContext, typedData, legacyV)
+}
+
+// SignPersonalMessage calls the remote AWS KMS to sign the given message prefixed with
+// "\x19Ethereum Signed Message:\n" and its length (EIP-191). The returned v is either 27 or 28 (as returned by `personal_sign`).
+func (c AmazonKMSClient) SignPersonalMessage(msg []byte) ([]byte, error) {
+	return c.SignPersonalMessageWithContext(c.ctx, msg)
+}
+
+// SignPersonalMessageWithContext is the same as SignPersonalMessage, but the remote call is bound to the given context.
+func (c AmazonKMSClient) SignPersonalMessageWithContext(ctx context.Context, msg []byte) ([]byte, error) {
+	return common2.SignPersonalMessage(ctx, c.SignHashWithContext, msg)
+}
+
 // GetDefaultEVMTransactor returns the default KMS-backed instance of bind.TransactOpts.
 // Only `Context`, `From`, and `Signer` fields are set.
 func (c AmazonKMSClient) GetDefaultEVMTransactor() *bind.TransactOpts {
-	return &bind.TransactOpts{
-		Context: c.ctx,
-		From:    c.GetAddress(),
-		Signer:  c.GetEVMSignerFn(),
-	}
+	return c.GetDefaultEVMTransactorWithContext(c.ctx)
+}
+
+// GetDefaultEVMTransactorWithContext is the same as GetDefaultEVMTransactor, but the given context is used for both
+// the transactor and the KMS calls made by its signer.
+func (c AmazonKMSClient) GetDefaultEVMTransactorWithContext(ctx context.Context) *bind.TransactOpts {
+	return common2.NewTransactor(ctx, c.GetAddress(), c.GetEVMSignerFnWithContext(ctx))
 }
 
 // GetEVMSignerFn returns the EVM signer using the AWS KMS.
 func (c AmazonKMSClient) GetEVMSignerFn() bind.SignerFn {
-	return func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
-		if addr != c.GetAddress() {
-			return nil, bind.ErrNotAuthorized
-		}
-
-		sig, err := c.SignHash(c.signer.Hash(tx))
-		if err != nil {
-			return nil, fmt.Errorf("cannot sign transaction: %v", err)
-		}
-
-		ret, err := tx.WithSignature(c.signer, sig)
-		if err != nil {
-			return nil, err
-		}
-
-		if _, err = c.HasSignedTx(ret); err != nil {
-			return nil, err
-		}
+	return c.GetEVMSignerFnWithContext(c.ctx)
+}
 
-		return ret, nil
-	}
+// GetEVMSignerFnWithContext returns the EVM signer using the AWS KMS, whose remote calls are bound to the given context.
+func (c AmazonKMSClient) GetEVMSignerFnWithContext(ctx context.Context) bind.SignerFn {
+	return common2.NewSignerFn(ctx, c.GetAddress(), c.signer, c.SignHashWithContext)
 }
 
 // HasSignedTx checks if the given tx is signed by the current AmazonKMSClient.
 func (c AmazonKMSClient) HasSignedTx(tx *types.Transaction) (bool, error) {
-	from, err := types.Sender(c.signer, tx)
-	if err != nil {
-		return false, fmt.Errorf("cannot get sender of the tx: %v", err)
-	}
-
-	if from != c.GetAddress() {
-		return false, fmt.Errorf("expected signer: %v, got %v", c.GetAddress(), from)
-	}
-
-	return true, nil
+	return common2.HasSignedTx(c.signer, c.GetAddress(), tx)
 }
 
 // WithSigner assigns the given signer to the AmazonKMSClient.
@@ -200,10 +207,7 @@ func (c *AmazonKMSClient) WithSigner(signer types.Signer) {
 
 // WithChainID assigns given chainID (and updates the corresponding signer) to the AmazonKMSClient.
 func (c *AmazonKMSClient) WithChainID(chainID *big.Int) {
-	if c.cfg.ChainID != chainID.Uint64() {
-		c.cfg.ChainID = chainID.Uint64()
-		c.signer = types.NewLondonSigner(chainID)
-	}
+	c.cfg.ChainID, c.signer = common2.SwitchChainID(c.cfg.ChainID, c.signer, chainID)
 }
 
 func (c AmazonKMSClient) getPublicKey() (*ecdsa.PublicKey, error) {
@@ -211,12 +215,24 @@ func (c AmazonKMSClient) getPublicKey() (*ecdsa.PublicKey, error) {
 		KeyId: aws.String(c.cfg.KeyID),
 	})
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get public key from AWS KMS for KeyId=%v", c.cfg.KeyID)
+		return nil, wrapError("GetPublicKey", errors.Wrapf(err, "failed to get public key from AWS KMS for KeyId=%v", c.cfg.KeyID))
 	}
 
 	return parseKMSPublicKey(getPubKeyOutput)
 }
 
+// validateKey checks that the key is an enabled secp256k1 signing key.
+func (c AmazonKMSClient) validateKey() error {
+	output, err := c.kmsClient.DescribeKey(c.ctx, &kms.DescribeKeyInput{
+		KeyId: aws.String(c.cfg.KeyID),
+	})
+	if err != nil {
+		return wrapError("DescribeKey", errors.Wrapf(err, "failed to describe key %v", c.cfg.KeyID))
+	}
+
+	return validateKeyMetadata(output.KeyMetadata)
+}
+
 // parseKMSSignature parses a signature returned from the AWS KMS to a valid EVM-compatible signature.
 // A valid EVM signature is a 65-byte long RLP-encoded of the form R || S || V (https://eips.ethereum.org/EIPS/eip-155).
 func (c AmazonKMSClient) parseKMSSignature(digestedMsg common.Hash,
@@ -226,7 +242,7 @@ func (c AmazonKMSClient) parseKMSSignature(digestedMsg common.Hash,
 	var sig common2.KmsSignature
 	_, err := asn1.Unmarshal(kmsSignature, &sig)
 	if err != nil {
-		return nil, fmt.Errorf("cannot unmarshal kms signature: %v", err)
+		return nil, fmt.Errorf("%w: cannot unmarshal kms signature: %v", common2.ErrSignatureInvalid, err)
 	}
 
 	// convert the signature into a valid EVM signature.
@@ -235,18 +251,43 @@ func (c AmazonKMSClient) parseKMSSignature(digestedMsg common.Hash,
 
 // parseKMSPublicKey parses a public Key returned from the AWS KMS to a valid ecdsa.PublicKey.
 func parseKMSPublicKey(kmsPubKey *kms.GetPublicKeyOutput) (*ecdsa.PublicKey, error) {
-	type pubKeyHolder struct {
-		EcPublicKeyInfo struct {
-			Algorithm  asn1.ObjectIdentifier
-			Parameters asn1.ObjectIdentifier
-		}
-		PublicKey asn1.BitString
+	if kmsPubKey.KeySpec != "" && kmsPubKey.KeySpec != kmstypes.KeySpecEccSecgP256k1 {
+		return nil, fmt.Errorf("%w: expected key spec %v, got %v",
+			common2.ErrWrongKeySpec, kmstypes.KeySpecEccSecgP256k1, kmsPubKey.KeySpec)
 	}
-	var pubKeyInfo pubKeyHolder
-	_, err := asn1.Unmarshal(kmsPubKey.PublicKey, &pubKeyInfo)
-	if err != nil || len(pubKeyInfo.PublicKey.Bytes) == 0 {
-		return nil, fmt.Errorf("cannot decode public key %x: %v", kmsPubKey.PublicKey, err)
+
+	pubKey, err := common2.ParsePKIXPublicKey(kmsPubKey.PublicKey)
+	if err != nil && !errors.Is(err, common2.ErrWrongKeySpec) {
+		return nil, fmt.Errorf("%w: %v", common2.ErrWrongKeySpec, err)
+	}
+
+	return pubKey, err
+}
+
+// validateKeyMetadata checks that the given key is an enabled secp256k1 signing key.
+func validateKeyMetadata(metadata *kmstypes.KeyMetadata) error {
+	if metadata == nil {
+		return fmt.Errorf("%w: empty key metadata", common2.ErrKeyNotFound)
+	}
+
+	keySpec := metadata.KeySpec
+	if keySpec == "" {
+		keySpec = kmstypes.KeySpec(metadata.CustomerMasterKeySpec)
+	}
+	if keySpec != kmstypes.KeySpecEccSecgP256k1 {
+		return fmt.Errorf("%w: key %v has key spec %v, expected %v",
+			common2.ErrWrongKeySpec, aws.ToString(metadata.KeyId), keySpec, kmstypes.KeySpecEccSecgP256k1)
+	}
+
+	if metadata.KeyUsage != kmstypes.KeyUsageTypeSignVerify {
+		return fmt.Errorf("%w: key %v has key usage %v, expected %v",
+			common2.ErrWrongKeySpec, aws.ToString(metadata.KeyId), metadata.KeyUsage, kmstypes.KeyUsageTypeSignVerify)
+	}
+
+	if !metadata.Enabled || metadata.KeyState != kmstypes.KeyStateEnabled {
+		return fmt.Errorf("%w: key %v is in state %v",
+			common2.ErrKeyDisabled, aws.ToString(metadata.KeyId), metadata.KeyState)
 	}
 
-	return crypto.UnmarshalPubkey(pubKeyInfo.PublicKey.Bytes)
+	return nil
 }
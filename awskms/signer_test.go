@@ -5,15 +5,20 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
 	"github.com/LampardNguyen234/evm-kms/common/erc20"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	math2 "github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/signer/core"
 	"math"
 	"math/big"
+	"os"
+	"sync"
 	"testing"
 	"time"
 )
@@ -25,20 +30,38 @@ var (
 	numTests     = 10
 )
 
-var c *AmazonKMSClient
+// liveTestEnv is the environment variable holding the path of the static credentials config file of the live tests.
+const liveTestEnv = "AWSKMS_TEST_CONFIG"
 
-func init() {
-	ctx := context.Background()
+var (
+	c              *AmazonKMSClient
+	liveClientOnce sync.Once
+)
 
-	cfg, err := LoadStaticCredentialsConfigConfigFromFile("./config-static-credentials-example.json")
-	c, err = NewAmazonKMSClientWithStaticCredentials(ctx, *cfg)
-	if err != nil {
-		panic(err)
+// setupLiveClient initializes c with the config file given by liveTestEnv. The live tests require valid credentials
+// and network access, so they are skipped if liveTestEnv is not set.
+func setupLiveClient(t *testing.T) {
+	configFile := os.Getenv(liveTestEnv)
+	if configFile == "" {
+		t.Skipf("%v not set, skipping the live AWS KMS test", liveTestEnv)
 	}
-	c.WithChainID(new(big.Int).SetUint64(80001))
+
+	liveClientOnce.Do(func() {
+		cfg, err := LoadStaticCredentialsConfigConfigFromFile(configFile)
+		if err != nil {
+			panic(err)
+		}
+		c, err = NewAmazonKMSClientWithStaticCredentials(context.Background(), *cfg)
+		if err != nil {
+			panic(err)
+		}
+		c.WithChainID(new(big.Int).SetUint64(80001))
+	})
 }
 
 func TestAmazonKMSClient_GetPublicKey(t *testing.T) {
+	setupLiveClient(t)
+
 	pubKey, err := c.GetPublicKey()
 	if err != nil {
 		panic(err)
@@ -48,12 +71,16 @@ func TestAmazonKMSClient_GetPublicKey(t *testing.T) {
 }
 
 func TestAmazonKMSClient_GetAddress(t *testing.T) {
+	setupLiveClient(t)
+
 	address := c.GetAddress()
 
 	fmt.Printf("address: %v\n", address)
 }
 
 func TestAmazonKMSClient_Sign(t *testing.T) {
+	setupLiveClient(t)
+
 	msg := []byte("Hello World")
 	sig, err := c.SignHash(crypto.Keccak256Hash(msg))
 	if err != nil {
@@ -62,6 +89,83 @@ func TestAmazonKMSClient_Sign(t *testing.T) {
 	fmt.Printf("sig: %x\n", sig)
 }
 
+func TestAmazonKMSClient_SignHashWithContext(t *testing.T) {
+	setupLiveClient(t)
+
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := c.SignHashWithContext(ctx, digest); err != nil {
+		panic(err)
+	}
+
+	// a cancelled context must abort the remote call
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.SignHashWithContext(cancelledCtx, digest); err == nil {
+		panic("expected an error with a cancelled context")
+	}
+}
+
+func TestAmazonKMSClient_SignTypedData(t *testing.T) {
+	setupLiveClient(t)
+
+	typedData := core.TypedData{
+		Types: core.Types{
+			"EIP712Domain": []core.Type{
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"Greeting": []core.Type{
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Greeting",
+		Domain: core.TypedDataDomain{
+			Name:    "evm-kms",
+			ChainId: math2.NewHexOrDecimal256(1),
+		},
+		Message: core.TypedDataMessage{
+			"contents": "Hello World",
+		},
+	}
+
+	sig, err := c.SignTypedData(typedData, true)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("sig: %x\n", sig)
+
+	digest, err := common2.TypedDataHash(typedData)
+	if err != nil {
+		panic(err)
+	}
+	sig[64] -= 27
+	pubKey, err := crypto.SigToPub(digest[:], sig)
+	if err != nil {
+		panic(err)
+	}
+	if crypto.PubkeyToAddress(*pubKey) != c.GetAddress() {
+		panic("invalid typed data signature")
+	}
+}
+
+func TestAmazonKMSClient_SignPersonalMessage(t *testing.T) {
+	setupLiveClient(t)
+
+	msg := []byte("Hello World")
+	sig, err := c.SignPersonalMessage(msg)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("sig: %x\n", sig)
+
+	if _, err = common2.VerifyPersonalMessage(c.GetAddress(), msg, sig); err != nil {
+		panic(err)
+	}
+}
+
 func waitForReceipt(evmClient *ethclient.Client, txHash common.Hash) (*types.Receipt, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
@@ -82,6 +186,8 @@ func waitForReceipt(evmClient *ethclient.Client, txHash common.Hash) (*types.Rec
 }
 
 func TestSendETH(t *testing.T) {
+	setupLiveClient(t)
+
 	ctx := context.Background()
 	evmClient, err := ethclient.Dial(rpcHost)
 	if err != nil {
@@ -143,6 +249,8 @@ func TestSendETH(t *testing.T) {
 }
 
 func TestSendERC20(t *testing.T) {
+	setupLiveClient(t)
+
 	evmClient, err := ethclient.Dial(rpcHost)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,153 @@
+package azurekms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	vaultScope      = "https://vault.azure.net"
+	managedHSMScope = "https://managedhsm.azure.net"
+
+	// tokenExpiryDelta is the margin before the expiry of an access token at which it is refreshed.
+	tokenExpiryDelta = 2 * time.Minute
+)
+
+// imdsEndpoint is the Azure Instance Metadata Service endpoint used to acquire managed identity tokens.
+var imdsEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// tokenSource acquires and caches Azure Active Directory access tokens for the vault.
+type tokenSource struct {
+	httpClient *http.Client
+	cfg        Config
+
+	mtx       sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func newTokenSource(cfg Config, httpClient *http.Client) *tokenSource {
+	return &tokenSource{httpClient: httpClient, cfg: cfg}
+}
+
+// Token returns a valid access token, refreshing it if needed.
+func (ts *tokenSource) Token(ctx context.Context) (string, error) {
+	if ts.cfg.AccessToken != "" {
+		return ts.cfg.AccessToken, nil
+	}
+
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	if ts.token != "" && time.Now().Add(tokenExpiryDelta).Before(ts.expiresAt) {
+		return ts.token, nil
+	}
+
+	var err error
+	if ts.cfg.UseManagedIdentity {
+		ts.token, ts.expiresAt, err = ts.managedIdentityToken(ctx)
+	} else {
+		ts.token, ts.expiresAt, err = ts.clientSecretToken(ctx)
+	}
+	if err != nil {
+		ts.token = ""
+		return "", fmt.Errorf("failed to acquire access token: %w", err)
+	}
+
+	return ts.token, nil
+}
+
+// resource returns the resource (audience) of the vault.
+func (ts *tokenSource) resource() string {
+	u, err := url.Parse(ts.cfg.VaultURL)
+	if err == nil && strings.Contains(u.Host, ".managedhsm.") {
+		return managedHSMScope
+	}
+	return vaultScope
+}
+
+// clientSecretToken acquires a token using the OAuth2 client credentials flow.
+func (ts *tokenSource) clientSecretToken(ctx context.Context) (string, time.Time, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", ts.cfg.ClientID)
+	form.Set("client_secret", ts.cfg.ClientSecret)
+	form.Set("scope", ts.resource()+"/.default")
+
+	tokenURL := fmt.Sprintf("%v/%v/oauth2/v2.0/token", strings.TrimRight(ts.cfg.authorityHost(), "/"), ts.cfg.TenantID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return ts.doTokenRequest(req)
+}
+
+// managedIdentityToken acquires a token from the Azure Instance Metadata Service.
+func (ts *tokenSource) managedIdentityToken(ctx context.Context) (string, time.Time, error) {
+	query := url.Values{}
+	query.Set("api-version", "2018-02-01")
+	query.Set("resource", ts.resource())
+	if ts.cfg.ClientID != "" {
+		query.Set("client_id", ts.cfg.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	return ts.doTokenRequest(req)
+}
+
+func (ts *tokenSource) doTokenRequest(req *http.Request) (string, time.Time, error) {
+	resp, err := ts.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var tokenResp struct {
+		AccessToken      string      `json:"access_token"`
+		ExpiresIn        json.Number `json:"expires_in"`
+		Error            string      `json:"error"`
+		ErrorDescription string      `json:"error_description"`
+	}
+	err = json.Unmarshal(respBody, &tokenResp)
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, &apiError{
+			StatusCode: resp.StatusCode,
+			Code:       tokenResp.Error,
+			Message:    tokenResp.ErrorDescription,
+		}
+	}
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("cannot decode token response: %v", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("empty access token")
+	}
+
+	// IMDS returns expires_in as a string, AAD as a number.
+	expiresIn, err := strconv.ParseInt(tokenResp.ExpiresIn.String(), 10, 64)
+	if err != nil {
+		expiresIn = 0
+	}
+
+	return tokenResp.AccessToken, time.Now().Add(time.Duration(expiresIn) * time.Second), nil
+}
@@ -0,0 +1,133 @@
+package azurekms
+
+import (
+	"encoding/json"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"os"
+)
+
+const defaultAuthorityHost = "https://login.microsoftonline.com"
+
+// Config represents required information to create an Azure Key Vault client.
+//
+// Exactly one authentication method must be provided: AccessToken, a service principal (TenantID, ClientID and
+// ClientSecret), or UseManagedIdentity.
+type Config struct {
+	// VaultURL is the URL of the Azure Key Vault or Managed HSM.
+	//
+	// Example: "https://my-vault.vault.azure.net" or "https://my-hsm.managedhsm.azure.net".
+	VaultURL string `json:"VaultURL"`
+
+	// KeyName is the name of the key in the vault.
+	KeyName string `json:"KeyName"`
+
+	// KeyVersion is the version of the key. If empty, the current version at construction time is used.
+	KeyVersion string `json:"KeyVersion,omitempty"`
+
+	// TenantID is the Azure Active Directory tenant of the service principal.
+	TenantID string `json:"TenantID,omitempty"`
+
+	// ClientID is the application (client) ID of the service principal, or of the user-assigned managed identity.
+	ClientID string `json:"ClientID,omitempty"`
+
+	// ClientSecret is the secret of the service principal.
+	ClientSecret string `json:"ClientSecret,omitempty"`
+
+	// UseManagedIdentity indicates whether to authenticate with the managed identity of the current Azure resource.
+	UseManagedIdentity bool `json:"UseManagedIdentity,omitempty"`
+
+	// AccessToken is a pre-acquired bearer token for the vault. It is not refreshed by the client.
+	AccessToken string `json:"AccessToken,omitempty"`
+
+	// AuthorityHost is the Azure Active Directory endpoint. Default: "https://login.microsoftonline.com".
+	AuthorityHost string `json:"AuthorityHost,omitempty"`
+
+	// ChainID is the ID of the target EVM chain.
+	//
+	// See https://chainlist.org.
+	ChainID uint64 `json:"ChainID"`
+}
+
+// IsValid checks if a Config is valid. Empty service principal credentials are valid if the corresponding environment
+// variables (`AZURE_TENANT_ID`, `AZURE_CLIENT_ID` and `AZURE_CLIENT_SECRET`) are set.
+func (cfg Config) IsValid() (bool, error) {
+	cfg = cfg.withEnvDefaults()
+
+	if cfg.VaultURL == "" {
+		return false, fmt.Errorf("empty VaultURL")
+	}
+
+	if cfg.KeyName == "" {
+		return false, fmt.Errorf("empty KeyName")
+	}
+
+	count := 0
+	if cfg.AccessToken != "" {
+		count++
+	}
+	if cfg.ClientSecret != "" {
+		if cfg.TenantID == "" || cfg.ClientID == "" {
+			return false, fmt.Errorf("empty TenantID or ClientID")
+		}
+		count++
+	}
+	if cfg.UseManagedIdentity {
+		count++
+	}
+
+	if count == 0 {
+		return false, fmt.Errorf("empty credentials")
+	}
+	if count > 1 {
+		return false, fmt.Errorf("only one of AccessToken, ClientSecret and UseManagedIdentity must be set")
+	}
+
+	return true, nil
+}
+
+// LoadConfigFromFile loads the config from the given config file.
+func LoadConfigFromFile(filePath string) (*Config, error) {
+	f, err := common2.ReadConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	err = json.Unmarshal(f, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg = cfg.withEnvDefaults()
+	if _, err = cfg.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// withEnvDefaults returns a copy of the Config whose empty TenantID, ClientID and ClientSecret are taken from the
+// environment variables used by the Azure SDKs, if no other authentication method is set.
+func (cfg Config) withEnvDefaults() Config {
+	if cfg.AccessToken != "" || cfg.ClientSecret != "" || cfg.UseManagedIdentity {
+		return cfg
+	}
+
+	if cfg.TenantID == "" {
+		cfg.TenantID = os.Getenv("AZURE_TENANT_ID")
+	}
+	if cfg.ClientID == "" {
+		cfg.ClientID = os.Getenv("AZURE_CLIENT_ID")
+	}
+	cfg.ClientSecret = os.Getenv("AZURE_CLIENT_SECRET")
+
+	return cfg
+}
+
+func (cfg Config) authorityHost() string {
+	if cfg.AuthorityHost == "" {
+		return defaultAuthorityHost
+	}
+	return cfg.AuthorityHost
+}
@@ -0,0 +1,28 @@
+package azurekms_test
+
+import (
+	"context"
+	kms "github.com/LampardNguyen234/evm-kms"
+	"github.com/LampardNguyen234/evm-kms/azurekms"
+	"github.com/LampardNguyen234/evm-kms/kmstest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	kmstest.RunConformance(t, func(t *testing.T) kms.KMSSigner {
+		server := azurekms.NewTestServer()
+		t.Cleanup(server.Close)
+
+		c, err := azurekms.NewAzureKMSClient(context.Background(), azurekms.Config{
+			VaultURL:    server.URL,
+			KeyName:     azurekms.TestKeyName,
+			AccessToken: azurekms.TestToken,
+			ChainID:     1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return c
+	})
+}
@@ -0,0 +1,7 @@
+// Package azurekms uses the Azure Key Vault (or Azure Managed HSM) to provide a signing interface for EVM-compatible
+// transactions.
+//
+// Rather than directly accessing a private key to sign a transaction, the client makes calls to the remote
+// Azure Key Vault to do so and the private key never leaves the vault. The key must be of type EC (or EC-HSM) with
+// the curve P-256K, and is used with the ES256K signing algorithm.
+package azurekms
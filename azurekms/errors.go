@@ -0,0 +1,65 @@
+package azurekms
+
+import (
+	"errors"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"net/http"
+)
+
+// apiError is an error response of the Azure Key Vault REST API, or of the Azure Active Directory (or managed
+// identity) token endpoint.
+type apiError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Code is the error code returned by Azure (e.g, "KeyNotFound" or "invalid_client"), if any.
+	Code string
+
+	// Message is the error message returned by Azure, if any.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *apiError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("azure returned status %v", e.StatusCode)
+	}
+
+	return fmt.Sprintf("azure returned status %v: %v: %v", e.StatusCode, e.Code, e.Message)
+}
+
+// wrapError wraps an error returned by the Azure Key Vault REST API (or the token endpoint) into a common2.KMSError, whose Kind is derived
+// from the HTTP status code.
+func wrapError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return common2.NewKMSError(op, errorKind(err), err)
+}
+
+// errorKind maps an error returned by the Azure Key Vault REST API to one of the sentinel errors of the common package.
+func errorKind(err error) error {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+
+	// the token endpoint returns 400 for some invalid credentials.
+	switch apiErr.Code {
+	case "invalid_client", "unauthorized_client", "invalid_grant":
+		return common2.ErrPermissionDenied
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return common2.ErrPermissionDenied
+	case http.StatusNotFound:
+		return common2.ErrKeyNotFound
+	case http.StatusTooManyRequests:
+		return common2.ErrThrottled
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package azurekms
+
+import (
+	"net/http/httptest"
+)
+
+// Exported for the external tests of the package.
+const (
+	TestToken   = testToken
+	TestKeyName = testKeyName
+)
+
+// NewTestServer returns a fake Azure Key Vault holding a secp256k1 key.
+func NewTestServer() *httptest.Server {
+	return newKeyVaultServer(curveName).Server
+}
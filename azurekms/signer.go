@@ -0,0 +1,341 @@
+package azurekms
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	apiVersion       = "7.4"
+	signingAlgorithm = "ES256K"
+	curveName        = "P-256K"
+)
+
+// AzureKMSClient implements basic functionalities of an Azure Key Vault client for signing transactions.
+type AzureKMSClient struct {
+	httpClient  *http.Client
+	tokenSource *tokenSource
+	ctx         context.Context
+	cfg         Config
+	publicKey   *ecdsa.PublicKey
+	signer      types.Signer
+}
+
+// NewAzureKMSClient creates a new Azure Key Vault client with the given config.
+//
+// If txSigner is not provided, the signer will be initiated as a types.NewLondonSigner(cfg.ChainID).
+// Note that only the first value of txSigner is used.
+//
+// Example:
+//
+//	cfg := Config{
+//		VaultURL:     "https://my-vault.vault.azure.net",
+//		KeyName:      "evm-ecdsa",
+//		TenantID:     "TENANT_ID",
+//		ClientID:     "CLIENT_ID",
+//		ClientSecret: "CLIENT_SECRET",
+//		ChainID:      1,
+//	}
+//
+//	c, err := NewAzureKMSClient(context.Background(), cfg)
+//	if err != nil {
+//		panic(err)
+//	}
+func NewAzureKMSClient(ctx context.Context, cfg Config, txSigner ...types.Signer) (*AzureKMSClient, error) {
+	return NewAzureKMSClientWithHTTPClient(ctx, cfg, http.DefaultClient, txSigner...)
+}
+
+// NewAzureKMSClientWithHTTPClient is an alternative of NewAzureKMSClient but uses the given http.Client to talk to
+// Azure Active Directory and the Key Vault.
+func NewAzureKMSClientWithHTTPClient(ctx context.Context,
+	cfg Config,
+	httpClient *http.Client,
+	txSigner ...types.Signer,
+) (*AzureKMSClient, error) {
+	cfg = cfg.withEnvDefaults()
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+
+	signer := common2.NewTxSigner(cfg.ChainID, txSigner...)
+
+	c := &AzureKMSClient{
+		httpClient:  httpClient,
+		tokenSource: newTokenSource(cfg, httpClient),
+		ctx:         ctx,
+		cfg:         cfg,
+		signer:      signer,
+	}
+
+	pubKey, keyVersion, err := c.getPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	c.publicKey = pubKey
+	// pin the key version, so that the address does not change when the key is rotated.
+	c.cfg.KeyVersion = keyVersion
+
+	return c, nil
+}
+
+// GetAddress returns the EVM address of the current signer.
+func (c AzureKMSClient) GetAddress() common.Address {
+	return crypto.PubkeyToAddress(*c.publicKey)
+}
+
+// GetPublicKey returns the public key of the selected version of the key.
+func (c AzureKMSClient) GetPublicKey() (*ecdsa.PublicKey, error) {
+	return c.publicKey, nil
+}
+
+// GetKeyVersion returns the version of the key used for signing.
+func (c AzureKMSClient) GetKeyVersion() string {
+	return c.cfg.KeyVersion
+}
+
+// SignHash calls the remote Azure Key Vault to sign a given digested message.
+// Although ES256K is specified with SHA256, the vault does not care about which hash function has been used to compute
+// the digest.
+func (c AzureKMSClient) SignHash(digest common.Hash) ([]byte, error) {
+	return c.SignHashWithContext(c.ctx, digest)
+}
+
+// SignHashWithContext is the same as SignHash, but the remote call is bound to the given context.
+func (c AzureKMSClient) SignHashWithContext(ctx context.Context, digest common.Hash) ([]byte, error) {
+	req := map[string]string{
+		"alg":   signingAlgorithm,
+		"value": base64.RawURLEncoding.EncodeToString(digest[:]),
+	}
+
+	var resp struct {
+		Kid   string `json:"kid"`
+		Value string `json:"value"`
+	}
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("keys/%v/%v/sign", c.cfg.KeyName, c.cfg.KeyVersion), req, &resp)
+	if err != nil {
+		return nil, wrapError("Sign", fmt.Errorf("failed to sign digest: %w", err))
+	}
+
+	return c.parseKMSSignature(digest, resp.Value)
+}
+
+// SignTypedData calls the remote Azure Key Vault to sign the EIP-712 digest of the given typed data.
+// If legacyV is set to true, the returned v will be either 27 or 28 (as returned by `eth_signTypedData_v4`).
+func (c AzureKMSClient) SignTypedData(typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return c.SignTypedDataWithContext(c.ctx, typedData, legacyV)
+}
+
+// SignTypedDataWithContext is the same as SignTypedData, but the remote call is bound to the given context.
+func (c AzureKMSClient) SignTypedDataWithContext(ctx context.Context, typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return common2.SignTypedData(ctx, c.SignHashWithContext, typedData, legacyV)
+}
+
+// SignPersonalMessage calls the remote Azure Key Vault to sign the given message prefixed with
+// "\x19Ethereum Signed Message:\n" and its length (EIP-191). The returned v is either 27 or 28 (as returned by `personal_sign`).
+func (c AzureKMSClient) SignPersonalMessage(msg []byte) ([]byte, error) {
+	return c.SignPersonalMessageWithContext(c.ctx, msg)
+}
+
+// SignPersonalMessageWithContext is the same as SignPersonalMessage, but the remote call is bound to the given context.
+func (c AzureKMSClient) SignPersonalMessageWithContext(ctx context.Context, msg []byte) ([]byte, error) {
+	return common2.SignPersonalMessage(ctx, c.SignHashWithContext, msg)
+}
+
+// GetDefaultEVMTransactor returns the default KMS-backed instance of bind.TransactOpts.
+// Only `Context`, `From`, and `Signer` fields are set.
+func (c AzureKMSClient) GetDefaultEVMTransactor() *bind.TransactOpts {
+	return c.GetDefaultEVMTransactorWithContext(c.ctx)
+}
+
+// GetDefaultEVMTransactorWithContext is the same as GetDefaultEVMTransactor, but the given context is used for both
+// the transactor and the Key Vault calls made by its signer.
+func (c AzureKMSClient) GetDefaultEVMTransactorWithContext(ctx context.Context) *bind.TransactOpts {
+	return common2.NewTransactor(ctx, c.GetAddress(), c.GetEVMSignerFnWithContext(ctx))
+}
+
+// GetEVMSignerFn returns the EVM signer using the Azure Key Vault.
+func (c AzureKMSClient) GetEVMSignerFn() bind.SignerFn {
+	return c.GetEVMSignerFnWithContext(c.ctx)
+}
+
+// GetEVMSignerFnWithContext returns the EVM signer using the Azure Key Vault, whose remote calls are bound to the given context.
+func (c AzureKMSClient) GetEVMSignerFnWithContext(ctx context.Context) bind.SignerFn {
+	return common2.NewSignerFn(ctx, c.GetAddress(), c.signer, c.SignHashWithContext)
+}
+
+// HasSignedTx checks if the given tx is signed by the current AzureKMSClient.
+func (c AzureKMSClient) HasSignedTx(tx *types.Transaction) (bool, error) {
+	return common2.HasSignedTx(c.signer, c.GetAddress(), tx)
+}
+
+// WithSigner assigns the given signer to the AzureKMSClient.
+func (c *AzureKMSClient) WithSigner(signer types.Signer) {
+	c.signer = signer
+}
+
+// WithChainID assigns given chainID (and updates the corresponding signer) to the AzureKMSClient.
+func (c *AzureKMSClient) WithChainID(chainID *big.Int) {
+	c.cfg.ChainID, c.signer = common2.SwitchChainID(c.cfg.ChainID, c.signer, chainID)
+}
+
+// jsonWebKey is the JWK representation of a key returned by the Azure Key Vault.
+type jsonWebKey struct {
+	Kid    string   `json:"kid"`
+	Kty    string   `json:"kty"`
+	Crv    string   `json:"crv"`
+	X      string   `json:"x"`
+	Y      string   `json:"y"`
+	KeyOps []string `json:"key_ops"`
+}
+
+// getPublicKey retrieves the public key of the configured (or current) version of the key, together with its version.
+func (c AzureKMSClient) getPublicKey() (*ecdsa.PublicKey, string, error) {
+	path := fmt.Sprintf("keys/%v", c.cfg.KeyName)
+	if c.cfg.KeyVersion != "" {
+		path = fmt.Sprintf("%v/%v", path, c.cfg.KeyVersion)
+	}
+
+	var resp struct {
+		Key        jsonWebKey `json:"key"`
+		Attributes struct {
+			Enabled bool `json:"enabled"`
+		} `json:"attributes"`
+	}
+	err := c.do(c.ctx, http.MethodGet, path, nil, &resp)
+	if err != nil {
+		return nil, "", wrapError("GetKey", fmt.Errorf("failed to get public key from Azure Key Vault for KeyName=%v: %w", c.cfg.KeyName, err))
+	}
+
+	if !resp.Attributes.Enabled {
+		return nil, "", fmt.Errorf("%w: key %v is disabled", common2.ErrKeyDisabled, resp.Key.Kid)
+	}
+
+	pubKey, err := parseKMSPublicKey(resp.Key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// the kid is of the form {vaultURL}/keys/{name}/{version}
+	keyVersion := resp.Key.Kid[strings.LastIndex(resp.Key.Kid, "/")+1:]
+	if keyVersion == "" {
+		return nil, "", fmt.Errorf("cannot retrieve key version from kid %v", resp.Key.Kid)
+	}
+
+	return pubKey, keyVersion, nil
+}
+
+// do performs a request to the Azure Key Vault REST API and decodes the response into the given result.
+func (c AzureKMSClient) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	token, err := c.tokenSource.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	reqURL := fmt.Sprintf("%v/%v?api-version=%v", strings.TrimRight(c.cfg.VaultURL, "/"), path, url.QueryEscape(apiVersion))
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(respBody, &errResp)
+		return &apiError{StatusCode: resp.StatusCode, Code: errResp.Error.Code, Message: errResp.Error.Message}
+	}
+
+	return json.Unmarshal(respBody, result)
+}
+
+// parseKMSSignature parses a signature returned from the Azure Key Vault to a valid EVM-compatible signature.
+// Unlike AWS and GCP, the Azure Key Vault returns the signature in the raw form r || s (base64url-encoded)
+// rather than ASN.1 DER.
+// A valid EVM signature is a 65-byte long RLP-encoded of the form R || S || V (https://eips.ethereum.org/EIPS/eip-155).
+func (c AzureKMSClient) parseKMSSignature(digestedMsg common.Hash,
+	azureSignature string,
+) ([]byte, error) {
+	rawSig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(azureSignature, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decode azure signature: %v", common2.ErrSignatureInvalid, err)
+	}
+
+	// recover r, s
+	sig, err := common2.ParseRawSignature(rawSig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal kms signature: %w", err)
+	}
+
+	// convert the signature into a valid EVM signature.
+	return common2.KmsToEVMSignature(*c.publicKey, sig, digestedMsg)
+}
+
+// parseKMSPublicKey parses a JWK returned from the Azure Key Vault to a valid ecdsa.PublicKey.
+func parseKMSPublicKey(jwk jsonWebKey) (*ecdsa.PublicKey, error) {
+	if jwk.Kty != "EC" && jwk.Kty != "EC-HSM" {
+		return nil, fmt.Errorf("unsupported key type %v, expected EC or EC-HSM", jwk.Kty)
+	}
+	if jwk.Crv != curveName {
+		return nil, fmt.Errorf("unsupported curve %v, expected %v", jwk.Crv, curveName)
+	}
+
+	xBytes, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk.X, "="))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode x coordinate: %v", err)
+	}
+	yBytes, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk.Y, "="))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode y coordinate: %v", err)
+	}
+
+	x := new(big.Int).SetBytes(xBytes)
+	y := new(big.Int).SetBytes(yBytes)
+
+	// check if the point is on the secp256k1 curve
+	if !secp256k1.S256().IsOnCurve(x, y) {
+		return nil, fmt.Errorf("invalid secp256k1 public key %v", jwk.Kid)
+	}
+
+	return &ecdsa.PublicKey{Curve: secp256k1.S256(), X: x, Y: y}, nil
+}
@@ -0,0 +1,294 @@
+package azurekms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const (
+	testTenantID     = "test-tenant"
+	testClientID     = "test-client"
+	testClientSecret = "test-secret"
+	testToken        = "test-access-token"
+	testKeyName      = "evm-ecdsa"
+	testKeyVersion   = "0123456789abcdef"
+)
+
+var receiverAddr = common.HexToAddress("0x243e9517a24813a2d73e9a74cd2c1c699d0ff7a5")
+
+// keyVaultServer is a minimal stand-in for Azure Active Directory and the Azure Key Vault REST API, backed by an
+// in-memory secp256k1 key.
+type keyVaultServer struct {
+	*httptest.Server
+	privateKey *ecdsa.PrivateKey
+	curve      string
+}
+
+func newKeyVaultServer(curve string) *keyVaultServer {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	s := &keyVaultServer{privateKey: privateKey, curve: curve}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+testTenantID+"/oauth2/v2.0/token", s.handleToken)
+	mux.HandleFunc("/keys/", s.authenticated(s.handleKeys))
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+func (s *keyVaultServer) writeError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"code": code, "message": code},
+	})
+}
+
+func (s *keyVaultServer) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			s.writeError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *keyVaultServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.PostForm.Get("client_id") != testClientID || r.PostForm.Get("client_secret") != testClientSecret ||
+		r.PostForm.Get("scope") != vaultScope+"/.default" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": testToken,
+		"expires_in":   3599,
+	})
+}
+
+func (s *keyVaultServer) handleKeys(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/keys/"), "/")
+	if parts[0] != testKeyName || (len(parts) > 1 && parts[1] != testKeyVersion) {
+		s.writeError(w, http.StatusNotFound, "KeyNotFound")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(parts) <= 2:
+		pubKey := s.privateKey.PublicKey
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"key": map[string]interface{}{
+				"kid":     fmt.Sprintf("%v/keys/%v/%v", s.URL, testKeyName, testKeyVersion),
+				"kty":     "EC-HSM",
+				"crv":     s.curve,
+				"x":       base64.RawURLEncoding.EncodeToString(pubKey.X.FillBytes(make([]byte, 32))),
+				"y":       base64.RawURLEncoding.EncodeToString(pubKey.Y.FillBytes(make([]byte, 32))),
+				"key_ops": []string{"sign", "verify"},
+			},
+			"attributes": map[string]interface{}{"enabled": true},
+		})
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "sign":
+		var req struct {
+			Alg   string `json:"alg"`
+			Value string `json:"value"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Alg != signingAlgorithm {
+			s.writeError(w, http.StatusBadRequest, "BadParameter")
+			return
+		}
+		digest, err := base64.RawURLEncoding.DecodeString(req.Value)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "BadParameter")
+			return
+		}
+		sig, err := crypto.Sign(digest, s.privateKey)
+		if err != nil {
+			panic(err)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"kid":   fmt.Sprintf("%v/keys/%v/%v", s.URL, testKeyName, testKeyVersion),
+			"value": base64.RawURLEncoding.EncodeToString(sig[:64]),
+		})
+	default:
+		s.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func TestNewAzureKMSClient(t *testing.T) {
+	server := newKeyVaultServer(curveName)
+	defer server.Close()
+
+	// service principal, current version
+	c, err := NewAzureKMSClient(context.Background(), Config{
+		VaultURL:      server.URL,
+		KeyName:       testKeyName,
+		TenantID:      testTenantID,
+		ClientID:      testClientID,
+		ClientSecret:  testClientSecret,
+		AuthorityHost: server.URL,
+		ChainID:       1,
+	})
+	if err != nil {
+		panic(err)
+	}
+	if c.GetKeyVersion() != testKeyVersion {
+		panic(fmt.Sprintf("expected key version %v, got %v", testKeyVersion, c.GetKeyVersion()))
+	}
+	if c.GetAddress() != crypto.PubkeyToAddress(server.privateKey.PublicKey) {
+		panic("address mismatch")
+	}
+
+	// wrong secret
+	_, err = NewAzureKMSClient(context.Background(), Config{
+		VaultURL:      server.URL,
+		KeyName:       testKeyName,
+		TenantID:      testTenantID,
+		ClientID:      testClientID,
+		ClientSecret:  "wrong-secret",
+		AuthorityHost: server.URL,
+	})
+	if !errors.Is(err, common2.ErrPermissionDenied) {
+		panic(fmt.Sprintf("expected %v with a wrong client secret, got %v", common2.ErrPermissionDenied, err))
+	}
+
+	// wrong token
+	_, err = NewAzureKMSClient(context.Background(), Config{
+		VaultURL:    server.URL,
+		KeyName:     testKeyName,
+		AccessToken: "wrong-token",
+	})
+	if !errors.Is(err, common2.ErrPermissionDenied) {
+		panic(fmt.Sprintf("expected %v, got %v", common2.ErrPermissionDenied, err))
+	}
+
+	// unknown key
+	_, err = NewAzureKMSClient(context.Background(), Config{
+		VaultURL:    server.URL,
+		KeyName:     "unknown-key",
+		AccessToken: testToken,
+	})
+	if !errors.Is(err, common2.ErrKeyNotFound) {
+		panic(fmt.Sprintf("expected %v, got %v", common2.ErrKeyNotFound, err))
+	}
+
+	// a P-256 key must be rejected
+	p256Server := newKeyVaultServer("P-256")
+	defer p256Server.Close()
+	_, err = NewAzureKMSClient(context.Background(), Config{
+		VaultURL:    p256Server.URL,
+		KeyName:     testKeyName,
+		AccessToken: testToken,
+	})
+	if err == nil {
+		panic("expected an error with a P-256 key")
+	}
+}
+
+func TestAzureKMSClient_Sign(t *testing.T) {
+	server := newKeyVaultServer(curveName)
+	defer server.Close()
+
+	c, err := NewAzureKMSClient(context.Background(), Config{
+		VaultURL:    server.URL,
+		KeyName:     testKeyName,
+		KeyVersion:  testKeyVersion,
+		AccessToken: testToken,
+		ChainID:     80001,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+	sig, err := c.SignHash(digest)
+	if err != nil {
+		panic(err)
+	}
+	pubKey, err := crypto.SigToPub(digest[:], sig)
+	if err != nil {
+		panic(err)
+	}
+	if crypto.PubkeyToAddress(*pubKey) != c.GetAddress() {
+		panic("invalid signature")
+	}
+
+	tx := types.NewTx(&types.AccessListTx{
+		ChainID:  big.NewInt(80001),
+		To:       &receiverAddr,
+		GasPrice: big.NewInt(1000000),
+		Gas:      50000,
+		Value:    big.NewInt(100),
+	})
+	signedTx, err := c.GetDefaultEVMTransactor().Signer(c.GetAddress(), tx)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = c.HasSignedTx(signedTx); err != nil {
+		panic(err)
+	}
+
+	if _, err = c.GetEVMSignerFn()(receiverAddr, tx); err != bind.ErrNotAuthorized {
+		panic(fmt.Sprintf("expected %v, got %v", bind.ErrNotAuthorized, err))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = c.SignHashWithContext(ctx, digest); !errors.Is(err, context.Canceled) {
+		panic(fmt.Sprintf("expected %v, got %v", context.Canceled, err))
+	}
+}
+
+func TestNewAzureKMSClient_Env(t *testing.T) {
+	server := newKeyVaultServer(curveName)
+	defer server.Close()
+
+	_ = os.Setenv("AZURE_TENANT_ID", "env-tenant")
+	_ = os.Setenv("AZURE_CLIENT_SECRET", testClientSecret)
+	defer os.Unsetenv("AZURE_TENANT_ID")
+	defer os.Unsetenv("AZURE_CLIENT_SECRET")
+
+	// the TenantID and ClientID of the config take precedence over the environment, and AZURE_CLIENT_ID is unset.
+	cfg := Config{
+		VaultURL:      server.URL,
+		KeyName:       testKeyName,
+		TenantID:      testTenantID,
+		ClientID:      testClientID,
+		AuthorityHost: server.URL,
+		ChainID:       1,
+	}
+	if _, err := cfg.IsValid(); err != nil {
+		panic(err)
+	}
+	if _, err := NewAzureKMSClient(context.Background(), cfg); err != nil {
+		panic(err)
+	}
+
+	// without AZURE_CLIENT_ID, an empty ClientID is invalid
+	cfg.ClientID = ""
+	if _, err := cfg.IsValid(); err == nil {
+		panic("expected an error with an empty ClientID")
+	}
+}
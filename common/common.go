@@ -33,7 +33,7 @@ func KmsToEVMSignature(pubKey ecdsa.PublicKey,
 
 	// re-verify the signature
 	if !ecdsa.Verify(&pubKey, digestedMsg[:], kmsSig.R, kmsSig.S) {
-		return nil, fmt.Errorf("failed to verify signature")
+		return nil, fmt.Errorf("%w: failed to verify signature", ErrSignatureInvalid)
 	}
 
 	// retrieve the bytes version of the public key for double-checking
@@ -47,7 +47,7 @@ func KmsToEVMSignature(pubKey ecdsa.PublicKey,
 	sig := append(rsSig, byte(v))
 	recoveredPubKey, err := crypto.Ecrecover(digestedMsg[:], sig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to recover pubKey with v = 0: %v", err)
+		return nil, fmt.Errorf("%w: failed to recover pubKey with v = 0: %v", ErrSignatureInvalid, err)
 	}
 
 	if !bytes.Equal(recoveredPubKey, pubKeyBytes) {
@@ -56,16 +56,29 @@ func KmsToEVMSignature(pubKey ecdsa.PublicKey,
 		sig = append(rsSig, byte(v))
 		recoveredPubKey, err = crypto.Ecrecover(digestedMsg[:], sig)
 		if err != nil {
-			return nil, fmt.Errorf("failed to recover pubKey with v = 1: %v", err)
+			return nil, fmt.Errorf("%w: failed to recover pubKey with v = 1: %v", ErrSignatureInvalid, err)
 		}
 		if !bytes.Equal(recoveredPubKey, pubKeyBytes) {
-			return nil, fmt.Errorf("cannot convert signature")
+			return nil, fmt.Errorf("%w: cannot convert signature", ErrSignatureInvalid)
 		}
 	}
 
 	return sig, nil
 }
 
+// ParseRawSignature parses a signature of the raw form r || s (e.g, IEEE P1363, JWS ES256K, PKCS#11 CKM_ECDSA), where
+// r and s are both 32-byte big-endian integers, into a KmsSignature.
+func ParseRawSignature(rawSig []byte) (KmsSignature, error) {
+	if len(rawSig) != 64 {
+		return KmsSignature{}, fmt.Errorf("%w: invalid raw signature length: expected 64, got %v", ErrSignatureInvalid, len(rawSig))
+	}
+
+	return KmsSignature{
+		R: new(big.Int).SetBytes(rawSig[:32]),
+		S: new(big.Int).SetBytes(rawSig[32:]),
+	}, nil
+}
+
 func pad(input []byte, paddedLength int) []byte {
 	input = bytes.TrimLeft(input, "\x00")
 	for len(input) < paddedLength {
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+)
+
+func TestKmsToEVMSignature(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+	sig, err := crypto.Sign(digest[:], privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	kmsSig, err := ParseRawSignature(sig[:64])
+	if err != nil {
+		panic(err)
+	}
+	// use the high-S form of the signature, as returned by most KMSs.
+	kmsSig.S = new(big.Int).Sub(CurveOrder, kmsSig.S)
+
+	evmSig, err := KmsToEVMSignature(privateKey.PublicKey, kmsSig, digest)
+	if err != nil {
+		panic(err)
+	}
+	if string(evmSig) != string(sig) {
+		panic("signature mismatch")
+	}
+
+	if _, err = ParseRawSignature(sig); err == nil {
+		panic("expected an error for a 65-byte raw signature")
+	}
+}
@@ -12,3 +12,6 @@ var (
 	// CurveOrderHalf = CurveOrder / 2.
 	CurveOrderHalf = new(big.Int).Div(CurveOrder, new(big.Int).SetUint64(2))
 )
+
+// LegacyVOffset is the offset added to the recovery id v (0 or 1) to obtain the legacy form of v (27 or 28).
+const LegacyVOffset = 27
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors shared by all KMS backends. They can be checked via errors.Is, regardless of the backend and of
+// the underlying SDK error.
+var (
+	// ErrInvalidConfig indicates that the given config is invalid. It is not retryable.
+	ErrInvalidConfig = errors.New("invalid config")
+
+	// ErrKeyNotFound indicates that the key (or key version) does not exist. It is not retryable.
+	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrKeyDisabled indicates that the key exists but cannot be used, e.g. it is disabled or pending deletion.
+	ErrKeyDisabled = errors.New("key disabled")
+
+	// ErrWrongKeySpec indicates that the key is not a secp256k1 signing key. It is not retryable.
+	ErrWrongKeySpec = errors.New("wrong key spec")
+
+	// ErrSignatureInvalid indicates that the KMS returned a signature which is malformed, corrupted or does not
+	// match the public key.
+	ErrSignatureInvalid = errors.New("invalid signature")
+
+	// ErrThrottled indicates that the request has been rejected by a rate limit or a quota. It is retryable.
+	ErrThrottled = errors.New("throttled")
+
+	// ErrPermissionDenied indicates that the credentials are missing, invalid or not allowed to use the key.
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// KMSError is an error returned by a KMS operation. It wraps the underlying (SDK) error, which remains accessible
+// via errors.As, and matches its Kind via errors.Is.
+type KMSError struct {
+	// Op is the KMS operation which failed (e.g, "Sign").
+	Op string
+
+	// Kind is one of the sentinel errors of this package, or nil if the error could not be classified.
+	Kind error
+
+	// Err is the underlying error.
+	Err error
+}
+
+// NewKMSError returns a new KMSError.
+func NewKMSError(op string, kind error, err error) *KMSError {
+	return &KMSError{Op: op, Kind: kind, Err: err}
+}
+
+// Error implements the error interface.
+func (e *KMSError) Error() string {
+	msg := fmt.Sprintf("%v", e.Err)
+	if e.Kind != nil && e.Kind != e.Err {
+		msg = fmt.Sprintf("%v: %v", e.Kind, msg)
+	}
+	if e.Op != "" {
+		msg = fmt.Sprintf("%v: %v", e.Op, msg)
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *KMSError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the Kind of the KMSError.
+func (e *KMSError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type sdkError struct {
+	code string
+}
+
+func (e *sdkError) Error() string {
+	return e.code
+}
+
+func TestKMSError(t *testing.T) {
+	sdkErr := &sdkError{code: "ThrottlingException"}
+	err := fmt.Errorf("cannot sign transaction: %w", NewKMSError("Sign", ErrThrottled, sdkErr))
+
+	if !errors.Is(err, ErrThrottled) {
+		panic("expected ErrThrottled")
+	}
+	if errors.Is(err, ErrPermissionDenied) {
+		panic("unexpected ErrPermissionDenied")
+	}
+
+	var target *sdkError
+	if !errors.As(err, &target) || target.code != sdkErr.code {
+		panic("expected the SDK error")
+	}
+
+	var kmsErr *KMSError
+	if !errors.As(err, &kmsErr) || kmsErr.Op != "Sign" {
+		panic("expected a KMSError")
+	}
+
+	expected := "cannot sign transaction: Sign: throttled: ThrottlingException"
+	if err.Error() != expected {
+		panic(fmt.Sprintf("expected message %v, got %v", expected, err.Error()))
+	}
+
+	// unclassified errors do not match any sentinel error
+	err = NewKMSError("Sign", nil, sdkErr)
+	for _, sentinel := range []error{ErrInvalidConfig, ErrKeyNotFound, ErrKeyDisabled, ErrWrongKeySpec,
+		ErrSignatureInvalid, ErrThrottled, ErrPermissionDenied} {
+		if errors.Is(err, sentinel) {
+			panic(fmt.Sprintf("unexpected %v", sentinel))
+		}
+	}
+}
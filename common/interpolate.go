@@ -0,0 +1,128 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	// filePrefix is the prefix of a string value referencing the content of a file.
+	filePrefix = "file://"
+)
+
+// ReadConfigFile reads the given JSON config file and resolves the references of its string values.
+// See InterpolateJSON for more detail.
+func ReadConfigFile(filePath string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return InterpolateJSON(data)
+}
+
+// InterpolateJSON resolves the references of all the string values (at any depth) of the given JSON document:
+//   - a value of the form "file://path" is replaced by the content of the file at path, without the trailing newline
+//     (e.g, a Kubernetes secret mounted as a file);
+//   - each "${ENV_VAR}" in a value is replaced by the value of the environment variable ENV_VAR, which must be set.
+//     Use "$${" to write a literal "${".
+//
+// Object keys and non-string values are left untouched.
+func InterpolateJSON(data []byte) ([]byte, error) {
+	// numbers are decoded as json.Number to keep their precision (e.g, large chain IDs)
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	v, err := Interpolate(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+// Interpolate resolves the references of all the string values of the given decoded JSON value (as returned by
+// json.Unmarshal into an interface{}). See InterpolateJSON for more detail.
+func Interpolate(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case string:
+		return InterpolateString(value)
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(value))
+		for k, elem := range value {
+			resolved, err := Interpolate(elem)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", k, err)
+			}
+			res[k] = resolved
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(value))
+		for i, elem := range value {
+			resolved, err := Interpolate(elem)
+			if err != nil {
+				return nil, fmt.Errorf("[%v]: %w", i, err)
+			}
+			res[i] = resolved
+		}
+		return res, nil
+	default:
+		return v, nil
+	}
+}
+
+// InterpolateString resolves the references of the given string value. See InterpolateJSON for more detail.
+func InterpolateString(s string) (string, error) {
+	if strings.HasPrefix(s, filePrefix) {
+		content, err := ioutil.ReadFile(strings.TrimPrefix(s, filePrefix))
+		if err != nil {
+			return "", fmt.Errorf("%w: cannot read referenced file: %v", ErrInvalidConfig, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+
+	var sb strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			sb.WriteString(s)
+			break
+		}
+
+		// escaped "$${"
+		if i > 0 && s[i-1] == '$' {
+			sb.WriteString(s[:i])
+			sb.WriteString("{")
+			s = s[i+2:]
+			continue
+		}
+
+		j := strings.Index(s[i:], "}")
+		if j < 0 {
+			return "", fmt.Errorf("%w: unterminated reference in %q", ErrInvalidConfig, s)
+		}
+		name := s[i+2 : i+j]
+		if name == "" {
+			return "", fmt.Errorf("%w: empty environment variable name in %q", ErrInvalidConfig, s)
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("%w: environment variable %v is not set", ErrInvalidConfig, name)
+		}
+
+		sb.WriteString(s[:i])
+		sb.WriteString(value)
+		s = s[i+j+1:]
+	}
+
+	return sb.String(), nil
+}
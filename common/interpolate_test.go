@@ -0,0 +1,77 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterpolateJSON(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := ioutil.WriteFile(secretFile, []byte("SECRET_ACCESS_KEY\n"), 0600); err != nil {
+		panic(err)
+	}
+	if err := os.Setenv("EVM_KMS_TEST_REGION", "us-west-1"); err != nil {
+		panic(err)
+	}
+	defer os.Unsetenv("EVM_KMS_TEST_REGION")
+
+	data, err := InterpolateJSON([]byte(fmt.Sprintf(`{
+		"Region": "${EVM_KMS_TEST_REGION}",
+		"Endpoint": "https://kms.${EVM_KMS_TEST_REGION}.amazonaws.com",
+		"SecretAccessKey": "file://%v",
+		"Escaped": "$${EVM_KMS_TEST_REGION}",
+		"ChainID": 18446744073709551615,
+		"Nested": {"Values": ["${EVM_KMS_TEST_REGION}", true]}
+	}`, secretFile)))
+	if err != nil {
+		panic(err)
+	}
+
+	var cfg struct {
+		Region          string
+		Endpoint        string
+		SecretAccessKey string
+		Escaped         string
+		ChainID         uint64
+		Nested          struct {
+			Values []interface{}
+		}
+	}
+	if err = json.Unmarshal(data, &cfg); err != nil {
+		panic(err)
+	}
+
+	if cfg.Region != "us-west-1" || cfg.Endpoint != "https://kms.us-west-1.amazonaws.com" {
+		panic(fmt.Sprintf("invalid interpolated values: %v, %v", cfg.Region, cfg.Endpoint))
+	}
+	if cfg.SecretAccessKey != "SECRET_ACCESS_KEY" {
+		panic(fmt.Sprintf("invalid file value: %q", cfg.SecretAccessKey))
+	}
+	if cfg.Escaped != "${EVM_KMS_TEST_REGION}" {
+		panic(fmt.Sprintf("invalid escaped value: %v", cfg.Escaped))
+	}
+	if cfg.ChainID != 18446744073709551615 {
+		panic(fmt.Sprintf("invalid ChainID: %v", cfg.ChainID))
+	}
+	if cfg.Nested.Values[0] != "us-west-1" || cfg.Nested.Values[1] != true {
+		panic(fmt.Sprintf("invalid nested values: %v", cfg.Nested.Values))
+	}
+}
+
+func TestInterpolateString_Errors(t *testing.T) {
+	for _, s := range []string{
+		"${EVM_KMS_TEST_UNSET}",
+		"${EVM_KMS_TEST_UNTERMINATED",
+		"${}",
+		"file:///non/existing/file",
+	} {
+		if _, err := InterpolateString(s); !errors.Is(err, ErrInvalidConfig) {
+			panic(fmt.Sprintf("%v: expected ErrInvalidConfig, got %v", s, err))
+		}
+	}
+}
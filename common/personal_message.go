@@ -0,0 +1,45 @@
+package common
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// PersonalMessageHash computes the EIP-191 digest of the given message, i.e,
+// keccak256("\x19Ethereum Signed Message:\n" || len(msg) || msg).
+//
+// Reference: https://eips.ethereum.org/EIPS/eip-191.
+func PersonalMessageHash(msg []byte) common.Hash {
+	return common.BytesToHash(accounts.TextHash(msg))
+}
+
+// VerifyPersonalMessage checks if the given signature is a valid `personal_sign` signature of the given message
+// produced by the given address. Both forms of v (0/1 and 27/28) are accepted.
+func VerifyPersonalMessage(address common.Address, msg []byte, sig []byte) (bool, error) {
+	if len(sig) != crypto.SignatureLength {
+		return false, fmt.Errorf("invalid signature length: expected %v, got %v", crypto.SignatureLength, len(sig))
+	}
+
+	rawSig := make([]byte, len(sig))
+	copy(rawSig, sig)
+	if rawSig[crypto.RecoveryIDOffset] >= LegacyVOffset {
+		rawSig[crypto.RecoveryIDOffset] -= LegacyVOffset
+	}
+	if rawSig[crypto.RecoveryIDOffset] > 1 {
+		return false, fmt.Errorf("invalid recovery id %v", sig[crypto.RecoveryIDOffset])
+	}
+
+	digest := PersonalMessageHash(msg)
+	pubKey, err := crypto.SigToPub(digest[:], rawSig)
+	if err != nil {
+		return false, fmt.Errorf("cannot recover public key: %v", err)
+	}
+
+	if recovered := crypto.PubkeyToAddress(*pubKey); recovered != address {
+		return false, fmt.Errorf("expected signer: %v, got %v", address, recovered)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"testing"
+)
+
+func TestVerifyPersonalMessage(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	msg := []byte("Hello World")
+	digest := PersonalMessageHash(msg)
+	sig, err := crypto.Sign(digest[:], privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	// v = 0/1
+	if _, err = VerifyPersonalMessage(address, msg, sig); err != nil {
+		panic(err)
+	}
+
+	// v = 27/28
+	legacySig, err := ToLegacyV(sig)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = VerifyPersonalMessage(address, msg, legacySig); err != nil {
+		panic(err)
+	}
+
+	// wrong message
+	if ok, _ := VerifyPersonalMessage(address, []byte("Hello Bob"), legacySig); ok {
+		panic("expected an invalid signature for a different message")
+	}
+
+	// wrong address
+	otherAddress := common.HexToAddress("0x243e9517a24813a2d73e9a74cd2c1c699d0ff7a5")
+	if ok, _ := VerifyPersonalMessage(otherAddress, msg, legacySig); ok {
+		panic("expected an invalid signature for a different address")
+	}
+
+	// invalid v
+	legacySig[64] = 29
+	if ok, _ := VerifyPersonalMessage(address, msg, legacySig); ok {
+		panic("expected an invalid signature for v = 29")
+	}
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	minPollInterval = 100 * time.Millisecond
+	maxPollInterval = 2 * time.Second
+)
+
+// PollUntil calls condition until it returns true or an error, or until ctx is done. The interval between two
+// calls starts at 100ms and doubles up to 2s.
+func PollUntil(ctx context.Context, condition func(ctx context.Context) (bool, error)) error {
+	interval := minPollInterval
+	for {
+		done, err := condition(ctx)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"fmt"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// OIDPublicKeyECDSA is the ASN.1 object identifier of elliptic curve public keys (RFC 5480).
+	OIDPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+	// OIDNamedCurveSecp256k1 is the ASN.1 object identifier of the secp256k1 curve (SEC 2).
+	OIDNamedCurveSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// subjectPublicKeyInfo is the ASN.1 structure of an elliptic curve public key (RFC 5480).
+type subjectPublicKeyInfo struct {
+	Algorithm struct {
+		Algorithm  asn1.ObjectIdentifier
+		Parameters asn1.ObjectIdentifier
+	}
+	PublicKey asn1.BitString
+}
+
+// ParsePKIXPublicKey parses a DER-encoded SubjectPublicKeyInfo (RFC 5280) holding a secp256k1 public key.
+//
+// Unlike x509.ParsePKIXPublicKey, it supports the secp256k1 curve, and rejects keys on other curves with an error
+// matching ErrWrongKeySpec.
+func ParsePKIXPublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	var pubKeyInfo subjectPublicKeyInfo
+	_, err := asn1.Unmarshal(der, &pubKeyInfo)
+	if err != nil || len(pubKeyInfo.PublicKey.Bytes) == 0 {
+		return nil, fmt.Errorf("cannot decode public key %x: %v", der, err)
+	}
+
+	if !pubKeyInfo.Algorithm.Algorithm.Equal(OIDPublicKeyECDSA) {
+		return nil, fmt.Errorf("%w: unsupported public key algorithm %v", ErrWrongKeySpec, pubKeyInfo.Algorithm.Algorithm)
+	}
+	if !pubKeyInfo.Algorithm.Parameters.Equal(OIDNamedCurveSecp256k1) {
+		return nil, fmt.Errorf("%w: unsupported curve %v, expected secp256k1", ErrWrongKeySpec, pubKeyInfo.Algorithm.Parameters)
+	}
+
+	return crypto.UnmarshalPubkey(pubKeyInfo.PublicKey.Bytes)
+}
+
+// MarshalPKIXPublicKey encodes a secp256k1 public key into a DER-encoded SubjectPublicKeyInfo (RFC 5280).
+func MarshalPKIXPublicKey(pubKey *ecdsa.PublicKey) ([]byte, error) {
+	var pubKeyInfo subjectPublicKeyInfo
+	pubKeyInfo.Algorithm.Algorithm = OIDPublicKeyECDSA
+	pubKeyInfo.Algorithm.Parameters = OIDNamedCurveSecp256k1
+
+	pubKeyBytes := crypto.FromECDSAPub(pubKey)
+	pubKeyInfo.PublicKey = asn1.BitString{Bytes: pubKeyBytes, BitLength: 8 * len(pubKeyBytes)}
+
+	return asn1.Marshal(pubKeyInfo)
+}
@@ -0,0 +1,43 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"github.com/ethereum/go-ethereum/crypto"
+	"testing"
+)
+
+func TestParsePKIXPublicKey(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+
+	der, err := MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+
+	pubKey, err := ParsePKIXPublicKey(der)
+	if err != nil {
+		panic(err)
+	}
+	if crypto.PubkeyToAddress(*pubKey) != crypto.PubkeyToAddress(privateKey.PublicKey) {
+		panic("public key mismatch")
+	}
+
+	// a P-256 key must be rejected
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	der, err = x509.MarshalPKIXPublicKey(&p256Key.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = ParsePKIXPublicKey(der); err == nil {
+		panic("expected an error for a P-256 public key")
+	}
+}
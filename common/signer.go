@@ -0,0 +1,116 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"math/big"
+)
+
+// SignHashFn signs the given digested message, the remote call (if any) being bound to the given context. It is
+// typically the SignHashWithContext method of a KMS client, on top of which the helpers of this file implement the
+// rest of the signing operations of a KMS client.
+type SignHashFn func(ctx context.Context, digest common.Hash) ([]byte, error)
+
+// NewTxSigner returns the first value of txSigner if any, or a types.NewLondonSigner(chainID) otherwise.
+func NewTxSigner(chainID uint64, txSigner ...types.Signer) types.Signer {
+	if len(txSigner) > 0 {
+		return txSigner[0]
+	}
+
+	return types.NewLondonSigner(new(big.Int).SetUint64(chainID))
+}
+
+// SwitchChainID returns the new chainID and its types.NewLondonSigner if it differs from the current chainID, or the
+// current chainID and txSigner otherwise.
+func SwitchChainID(currentChainID uint64, txSigner types.Signer, chainID *big.Int) (uint64, types.Signer) {
+	if currentChainID == chainID.Uint64() {
+		return currentChainID, txSigner
+	}
+
+	return chainID.Uint64(), types.NewLondonSigner(chainID)
+}
+
+// SignTypedData signs the EIP-712 digest of the given typed data with signHash.
+// If legacyV is set to true, the returned v will be either 27 or 28 (as returned by `eth_signTypedData_v4`).
+func SignTypedData(ctx context.Context, signHash SignHashFn, typedData core.TypedData, legacyV bool) ([]byte, error) {
+	digest, err := TypedDataHash(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compute typed data hash: %v", err)
+	}
+
+	sig, err := signHash(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	if legacyV {
+		return ToLegacyV(sig)
+	}
+
+	return sig, nil
+}
+
+// SignPersonalMessage signs the given message prefixed with "\x19Ethereum Signed Message:\n" and its length (EIP-191)
+// with signHash. The returned v is either 27 or 28 (as returned by `personal_sign`).
+func SignPersonalMessage(ctx context.Context, signHash SignHashFn, msg []byte) ([]byte, error) {
+	sig, err := signHash(ctx, PersonalMessageHash(msg))
+	if err != nil {
+		return nil, err
+	}
+
+	return ToLegacyV(sig)
+}
+
+// NewTransactor returns a bind.TransactOpts sending from the given address with the given signerFn.
+// Only `Context`, `From`, and `Signer` fields are set.
+func NewTransactor(ctx context.Context, from common.Address, signerFn bind.SignerFn) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		Context: ctx,
+		From:    from,
+		Signer:  signerFn,
+	}
+}
+
+// NewSignerFn returns a bind.SignerFn which signs the transactions of the given address with signHash, and checks
+// the sender of the signed transactions. It returns bind.ErrNotAuthorized for any other address.
+func NewSignerFn(ctx context.Context, address common.Address, txSigner types.Signer, signHash SignHashFn) bind.SignerFn {
+	return func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if addr != address {
+			return nil, bind.ErrNotAuthorized
+		}
+
+		sig, err := signHash(ctx, txSigner.Hash(tx))
+		if err != nil {
+			return nil, fmt.Errorf("cannot sign transaction: %w", err)
+		}
+
+		ret, err := tx.WithSignature(txSigner, sig)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = HasSignedTx(txSigner, address, ret); err != nil {
+			return nil, err
+		}
+
+		return ret, nil
+	}
+}
+
+// HasSignedTx checks if the given tx is signed by the given address.
+func HasSignedTx(txSigner types.Signer, address common.Address, tx *types.Transaction) (bool, error) {
+	from, err := types.Sender(txSigner, tx)
+	if err != nil {
+		return false, fmt.Errorf("cannot get sender of the tx: %v", err)
+	}
+
+	if from != address {
+		return false, fmt.Errorf("expected signer: %v, got %v", address, from)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+)
+
+func TestNewSignerFn(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	signHash := func(ctx context.Context, digest common.Hash) ([]byte, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return crypto.Sign(digest[:], privateKey)
+	}
+
+	chainID, txSigner := SwitchChainID(1, NewTxSigner(1), big.NewInt(80001))
+	if chainID != 80001 || txSigner.ChainID().Uint64() != 80001 {
+		panic(fmt.Sprintf("expected chainID 80001, got %v", chainID))
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(80001),
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(1e10),
+		Gas:       21000,
+		To:        &common.Address{},
+		Value:     big.NewInt(1),
+	})
+	transactor := NewTransactor(context.Background(), address, NewSignerFn(context.Background(), address, txSigner, signHash))
+	signedTx, err := transactor.Signer(transactor.From, tx)
+	if err != nil {
+		panic(err)
+	}
+	if ok, err := HasSignedTx(txSigner, address, signedTx); !ok {
+		panic(err)
+	}
+	if ok, _ := HasSignedTx(txSigner, common.Address{}, signedTx); ok {
+		panic("expected HasSignedTx to fail for another address")
+	}
+
+	if _, err = transactor.Signer(common.Address{}, tx); err != bind.ErrNotAuthorized {
+		panic(fmt.Sprintf("expected %v, got %v", bind.ErrNotAuthorized, err))
+	}
+
+	sig, err := SignPersonalMessage(context.Background(), signHash, []byte("Hello World"))
+	if err != nil {
+		panic(err)
+	}
+	if ok, err := VerifyPersonalMessage(address, []byte("Hello World"), sig); !ok {
+		panic(err)
+	}
+}
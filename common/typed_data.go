@@ -0,0 +1,46 @@
+package common
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+// TypedDataHash computes the EIP-712 digest of the given typed data, i.e,
+// keccak256("\x19\x01" || hashStruct(domain) || hashStruct(message)).
+//
+// The typed data has the same JSON shape as the one used by wallets for `eth_signTypedData_v4`.
+// Reference: https://eips.ethereum.org/EIPS/eip-712.
+func TypedDataHash(typedData core.TypedData) (common.Hash, error) {
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("cannot hash domain: %v", err)
+	}
+
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("cannot hash message: %v", err)
+	}
+
+	rawData := append([]byte{0x19, 0x01}, append(domainSeparator, messageHash...)...)
+
+	return crypto.Keccak256Hash(rawData), nil
+}
+
+// ToLegacyV converts an EVM signature of the form r || s || v, with v either 0 or 1, into the form used by wallets
+// (e.g, `personal_sign`, `eth_signTypedData_v4`), with v either 27 or 28.
+func ToLegacyV(sig []byte) ([]byte, error) {
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("invalid signature length: expected %v, got %v", crypto.SignatureLength, len(sig))
+	}
+	if sig[crypto.RecoveryIDOffset] > 1 {
+		return nil, fmt.Errorf("invalid recovery id %v", sig[crypto.RecoveryIDOffset])
+	}
+
+	ret := make([]byte, len(sig))
+	copy(ret, sig)
+	ret[crypto.RecoveryIDOffset] += LegacyVOffset
+
+	return ret, nil
+}
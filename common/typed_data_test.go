@@ -0,0 +1,88 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"testing"
+)
+
+// mailTypedData is the example given in https://eips.ethereum.org/EIPS/eip-712.
+const mailTypedData = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": "1",
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+func TestTypedDataHash(t *testing.T) {
+	var typedData core.TypedData
+	err := json.Unmarshal([]byte(mailTypedData), &typedData)
+	if err != nil {
+		panic(err)
+	}
+
+	digest, err := TypedDataHash(typedData)
+	if err != nil {
+		panic(err)
+	}
+
+	expected := "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"
+	if digest.Hex() != expected {
+		panic(fmt.Sprintf("expected digest %v, got %v", expected, digest.Hex()))
+	}
+}
+
+func TestToLegacyV(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+	sig, err := crypto.Sign(digest[:], privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	legacySig, err := ToLegacyV(sig)
+	if err != nil {
+		panic(err)
+	}
+	if legacySig[64] != sig[64]+27 {
+		panic(fmt.Sprintf("expected v = %v, got %v", sig[64]+27, legacySig[64]))
+	}
+	if sig[64] > 1 {
+		panic("the original signature must not be modified")
+	}
+
+	if _, err = ToLegacyV(legacySig); err == nil {
+		panic("expected an error for an already-converted signature")
+	}
+}
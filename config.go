@@ -4,64 +4,136 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/LampardNguyen234/evm-kms/awskms"
+	"github.com/LampardNguyen234/evm-kms/azurekms"
 	"github.com/LampardNguyen234/evm-kms/gcpkms"
-	"io/ioutil"
-	"os"
+	"github.com/LampardNguyen234/evm-kms/keystorekms"
+	"github.com/LampardNguyen234/evm-kms/localkms"
+	"github.com/LampardNguyen234/evm-kms/vaultkms"
+	"reflect"
 	"strings"
 )
 
 const (
-	gcpType = "gcp"
-	awsType = "aws"
+	gcpType      = "gcp"
+	awsType      = "aws"
+	localType    = "local"
+	keystoreType = "keystore"
+	vaultType    = "vault"
+	azureType    = "azure"
+	pkcs11Type   = "pkcs11"
 )
 
 // Config is the holder for the KMS service.
 type Config struct {
-	// Type indicates which service we are using ('gcp', 'aws').
+	// Type indicates which service we are using ('gcp', 'aws', 'local', 'keystore', 'vault', 'azure', or any backend registered via RegisterBackend).
 	Type string `json:"type"`
 
 	// GcpConfig is the detail of the GCP KMS Config.
 	GcpConfig gcpkms.Config `json:"gcp"`
 
 	// AwsConfig is the detail of the AWS KMS Config.
-	AwsConfig awskms.StaticCredentialsConfig `json:"aws"`
+	AwsConfig awskms.CredentialsConfig `json:"aws"`
+
+	// LocalConfig is the detail of the local (in-memory private key) KMS Config.
+	LocalConfig localkms.Config `json:"local"`
+
+	// KeystoreConfig is the detail of the keystore KMS Config.
+	KeystoreConfig keystorekms.Config `json:"keystore"`
+
+	// VaultConfig is the detail of the HashiCorp Vault Transit Config.
+	VaultConfig vaultkms.Config `json:"vault"`
+
+	// AzureConfig is the detail of the Azure Key Vault Config.
+	AzureConfig azurekms.Config `json:"azure"`
+
+	// RawConfigs holds the raw config sections of the file, keyed by their field names.
+	// It is used to configure backends registered via RegisterBackend.
+	RawConfigs map[string]json.RawMessage `json:"-"`
 }
 
-// IsValid checks if the current Config is valid.
-func (cfg Config) IsValid() (bool, error) {
-	switch cfg.Type {
-	case awsType:
-		return cfg.AwsConfig.IsValid()
-	case gcpType:
-		return cfg.GcpConfig.IsValid()
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (cfg *Config) UnmarshalJSON(data []byte) error {
+	type configAlias Config
+	var tmp configAlias
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &tmp.RawConfigs); err != nil {
+		return err
 	}
+	delete(tmp.RawConfigs, "type")
 
-	return false, fmt.Errorf("KMS Config type `%v` not supported", strings.ToLower(cfg.Type))
+	*cfg = Config(tmp)
+	return nil
 }
 
-// LoadConfigFromJSONFile creates a Config from the given the json config file.
-func LoadConfigFromJSONFile(filePath string) (*Config, error) {
-	f, err := os.Open(filePath)
+// IsValid checks if the current Config is valid, i.e, its type is a registered backend and its config section is
+// valid for this backend (see RegisterBackendWithConfig).
+func (cfg Config) IsValid() (bool, error) {
+	b, err := getBackend(cfg.Type)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	bytesValue, err := ioutil.ReadAll(f)
+	rawConfig, err := cfg.backendConfig()
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	var res map[string]interface{}
-	err = json.Unmarshal(bytesValue, &res)
-	if err != nil {
-		return nil, err
+	if err = b.validateConfig(rawConfig); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// backendConfig returns the config section of the selected backend. The typed field of the same name (e.g, AwsConfig
+// for `aws`) is used if set, so that a Config built in code needs no RawConfigs; otherwise the raw section is used.
+func (cfg Config) backendConfig() (json.RawMessage, error) {
+	name := strings.ToLower(cfg.Type)
+	if typedConfig, ok := cfg.typedConfig(name); ok {
+		return json.Marshal(typedConfig)
+	}
+
+	rawConfig, ok := cfg.RawConfigs[name]
+	if !ok || len(rawConfig) == 0 {
+		return nil, fmt.Errorf("missing `%v` config section", name)
 	}
 
-	return LoadConfig(res)
+	return rawConfig, nil
+}
+
+// typedConfig returns the value of the typed field of the Config with the given JSON name, if it is set.
+func (cfg Config) typedConfig(name string) (interface{}, bool) {
+	v := reflect.ValueOf(cfg)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Name == "Type" || strings.Split(field.Tag.Get("json"), ",")[0] != name {
+			continue
+		}
+		if v.Field(i).IsZero() {
+			return nil, false
+		}
+
+		return v.Field(i).Interface(), true
+	}
+
+	return nil, false
+}
+
+// LoadConfigFromJSONFile creates a Config from the given the json config file, whatever its extension.
+// See LoadConfigFromFile for more detail.
+func LoadConfigFromJSONFile(filePath string) (*Config, error) {
+	return loadConfigFromFile(filePath, formatJSON)
 }
 
 // LoadConfig creates a Config from the given raw config data.
 func LoadConfig(rawConfig map[string]interface{}) (*Config, error) {
+	if _, ok := rawConfig["signers"]; ok {
+		return nil, fmt.Errorf("the config declares many signers, use LoadMultiConfig instead")
+	}
+
 	jsb, err := json.Marshal(rawConfig)
 	if err != nil {
 		return nil, err
@@ -2,7 +2,12 @@ package kms
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/LampardNguyen234/evm-kms/awskms"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -17,3 +22,63 @@ func TestLoadConfigFromJsonFile(t *testing.T) {
 	jsb, _ := json.MarshalIndent(cfg, "", "\t")
 	fmt.Println(string(jsb))
 }
+
+func TestLoadConfigFromJSONFile_Interpolation(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret-access-key")
+	if err := ioutil.WriteFile(secretFile, []byte("SECRET_ACCESS_KEY\n"), 0600); err != nil {
+		panic(err)
+	}
+	if err := os.Setenv("EVM_KMS_TEST_SESSION_TOKEN", "SESSION_TOKEN"); err != nil {
+		panic(err)
+	}
+	defer os.Unsetenv("EVM_KMS_TEST_SESSION_TOKEN")
+
+	filePath := filepath.Join(dir, "config.json")
+	err := ioutil.WriteFile(filePath, []byte(fmt.Sprintf(`{
+		"type": "aws",
+		"aws": {
+			"KeyID": "KEY_ID",
+			"ChainID": 1,
+			"Region": "us-west-1",
+			"AccessKeyID": "ACCESS_KEY_ID",
+			"SecretAccessKey": "file://%v",
+			"SessionToken": "${EVM_KMS_TEST_SESSION_TOKEN}"
+		}
+	}`, secretFile)), 0600)
+	if err != nil {
+		panic(err)
+	}
+
+	cfg, err := LoadConfigFromJSONFile(filePath)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.AwsConfig.SecretAccessKey != "SECRET_ACCESS_KEY" || cfg.AwsConfig.SessionToken != "SESSION_TOKEN" {
+		panic(fmt.Sprintf("invalid interpolated credentials: %v, %v", cfg.AwsConfig.SecretAccessKey, cfg.AwsConfig.SessionToken))
+	}
+
+	os.Unsetenv("EVM_KMS_TEST_SESSION_TOKEN")
+	if _, err = LoadConfigFromJSONFile(filePath); !errors.Is(err, ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig, got %v", err))
+	}
+}
+
+func TestConfig_StaticCredentials(t *testing.T) {
+	// a Config built with a StaticCredentialsConfig
+	cfg := Config{
+		Type: awsType,
+		AwsConfig: awskms.StaticCredentialsConfig{
+			Config:          awskms.Config{KeyID: "KEY_ID", ChainID: 1},
+			Region:          "us-west-1",
+			AccessKeyID:     "ACCESS_KEY_ID",
+			SecretAccessKey: "SECRET_ACCESS_KEY",
+		},
+	}
+	if _, err := cfg.IsValid(); err != nil {
+		panic(err)
+	}
+	if mode := cfg.AwsConfig.GetCredentialsMode(); mode != awskms.CredentialsModeStatic {
+		panic(fmt.Sprintf("expected mode %v, got %v", awskms.CredentialsModeStatic, mode))
+	}
+}
@@ -0,0 +1,270 @@
+package kms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+	formatTOML = "toml"
+)
+
+// LoadConfigFromFile creates a Config from the given config file, whose format (JSON, YAML or TOML) is detected by
+// its extension (`.json`, `.yaml`/`.yml` or `.toml`).
+//
+// Unlike LoadConfig, unknown fields are rejected: the top-level fields must be `type` or the name of a registered
+// backend, and the fields of a backend section must match the field names of its config exactly (e.g, `KeyId` is
+// rejected in place of `KeyID`). The latter only applies to the backends whose config type is known, i.e. the
+// built-in backends and those registered via RegisterBackendWithConfig. The `${ENV_VAR}` and `file://path` references of the string values
+// are resolved before validation (see common.InterpolateJSON).
+func LoadConfigFromFile(filePath string) (*Config, error) {
+	format, err := configFormat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadConfigFromFile(filePath, format)
+}
+
+// LoadMultiConfigFromFile is the same as LoadConfigFromFile, but for a multi-signer config file (see MultiConfig).
+func LoadMultiConfigFromFile(filePath string) (*MultiConfig, error) {
+	format, err := configFormat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadMultiConfigFromFile(filePath, format)
+}
+
+// loadConfigFromFile creates a Config from the given config file in the given format, rejecting unknown fields.
+func loadConfigFromFile(filePath string, format string) (*Config, error) {
+	rawConfig, err := readConfigFile(filePath, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := rawConfig["signers"]; !ok {
+		if err = checkSignerFields(rawConfig, nil); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+	}
+
+	return LoadConfig(rawConfig)
+}
+
+// loadMultiConfigFromFile creates a MultiConfig from the given config file in the given format, rejecting unknown
+// fields.
+func loadMultiConfigFromFile(filePath string, format string) (*MultiConfig, error) {
+	rawConfig, err := readConfigFile(filePath, format)
+	if err != nil {
+		return nil, err
+	}
+
+	for field := range rawConfig {
+		if field != "signers" {
+			return nil, fmt.Errorf("%w: unknown field `%v`", ErrInvalidConfig, field)
+		}
+	}
+	signers, ok := rawConfig["signers"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: `signers` must be a list", ErrInvalidConfig)
+	}
+	for i, signer := range signers {
+		signerConfig, ok := signer.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: signers[%v] must be an object", ErrInvalidConfig, i)
+		}
+		if err = checkSignerFields(signerConfig, signerConfigFields); err != nil {
+			return nil, fmt.Errorf("%w: signers[%v]: %v", ErrInvalidConfig, i, err)
+		}
+	}
+
+	return LoadMultiConfig(rawConfig)
+}
+
+// configFormat returns the format of the given config file, detected by its extension.
+func configFormat(filePath string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return formatJSON, nil
+	case ".yaml", ".yml":
+		return formatYAML, nil
+	case ".toml":
+		return formatTOML, nil
+	default:
+		return "", fmt.Errorf("%w: unsupported config file extension `%v`, expected .json, .yaml, .yml or .toml",
+			ErrInvalidConfig, filepath.Ext(filePath))
+	}
+}
+
+// readConfigFile reads the given config file in the given format into a JSON object, and resolves the references of
+// its string values.
+func readConfigFile(filePath string, format string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case formatYAML:
+		var v map[string]interface{}
+		if err = yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("%w: invalid YAML: %v", ErrInvalidConfig, err)
+		}
+		data, err = json.Marshal(v)
+	case formatTOML:
+		var v map[string]interface{}
+		if err = toml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("%w: invalid TOML: %v", ErrInvalidConfig, err)
+		}
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot convert %v to JSON: %v", ErrInvalidConfig, format, err)
+	}
+
+	data, err = common2.InterpolateJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var res map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&res); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	return res, nil
+}
+
+// checkSignerFields checks that the fields of the given signer config are `type`, one of the given extra fields, or
+// the name of a registered backend, and that the section of a backend registered with a config type has no unknown
+// fields.
+func checkSignerFields(rawConfig map[string]interface{}, extraFields []string) error {
+	registered := make(map[string]bool)
+	for _, name := range RegisteredBackends() {
+		registered[name] = true
+	}
+	for _, field := range extraFields {
+		registered[field] = true
+	}
+
+	for field, section := range rawConfig {
+		if field == "type" {
+			continue
+		}
+		if !registered[field] {
+			return fmt.Errorf("unknown field `%v`", field)
+		}
+		if b, err := getBackend(field); err == nil && b.config != nil {
+			if err = checkFields(section, b.config, field); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkFields checks that the given decoded JSON value has no field unknown to the given type. Field names are
+// case-sensitive.
+func checkFields(value interface{}, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := structFields(t)
+		for key, elem := range object {
+			fieldType, ok := fields[key]
+			if !ok {
+				for name := range fields {
+					if strings.EqualFold(name, key) {
+						return fmt.Errorf("unknown field `%v.%v`, did you mean `%v`?", path, key, name)
+					}
+				}
+				return fmt.Errorf("unknown field `%v.%v`", path, key)
+			}
+			if err := checkFields(elem, fieldType, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key, elem := range object {
+			if err := checkFields(elem, t.Elem(), path+"."+key); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, elem := range list {
+			if err := checkFields(elem, t.Elem(), fmt.Sprintf("%v[%v]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// structFields returns the types of the JSON fields of the given struct type, keyed by their names. The fields of
+// embedded structs are promoted.
+func structFields(t reflect.Type) map[string]reflect.Type {
+	res := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, v := range structFields(embedded) {
+					res[k] = v
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		res[name] = f.Type
+	}
+
+	return res
+}
@@ -0,0 +1,127 @@
+package kms
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigFromFile(t *testing.T) {
+	if err := os.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET_ACCESS_KEY"); err != nil {
+		panic(err)
+	}
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+	cfg, err := LoadConfigFromFile("config-example.yaml")
+	if err != nil {
+		panic(err)
+	}
+	if cfg.Type != awsType || cfg.AwsConfig.KeyID != "KEY_ID" || cfg.AwsConfig.ChainID != 1 ||
+		cfg.AwsConfig.SecretAccessKey != "SECRET_ACCESS_KEY" {
+		panic(fmt.Sprintf("invalid YAML config %v", cfg.AwsConfig))
+	}
+
+	cfg, err = LoadConfigFromFile("config-example.toml")
+	if err != nil {
+		panic(err)
+	}
+	if cfg.Type != gcpType || cfg.GcpConfig.Key.Name != "evm-ecdsa" || cfg.GcpConfig.ChainID != 1 {
+		panic(fmt.Sprintf("invalid TOML config %v", cfg.GcpConfig))
+	}
+
+	cfg, err = LoadConfigFromFile("config-example.json")
+	if err != nil {
+		panic(err)
+	}
+	if cfg.Type != gcpType {
+		panic(fmt.Sprintf("invalid JSON config %v", cfg))
+	}
+}
+
+func TestLoadConfigFromFile_UnknownFields(t *testing.T) {
+	dir := t.TempDir()
+
+	tcs := []struct {
+		fileName string
+		content  string
+		errMsg   string
+	}{
+		{
+			fileName: "typo.yaml",
+			content:  "type: local\nlocal:\n  PrivateKey: \"0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318\"\n  ChainId: 1\n",
+			errMsg:   "did you mean `ChainID`?",
+		},
+		{
+			fileName: "typo.json",
+			content:  `{"type": "aws", "aws": {"KeyId": "KEY_ID"}}`,
+			errMsg:   "unknown field `aws.KeyId`",
+		},
+		{
+			fileName: "nested.toml",
+			content:  "type = \"gcp\"\n[gcp]\nProjectID = \"p\"\nLocationID = \"l\"\n[gcp.Key]\nKeyring = \"r\"\nName = \"n\"\nVersion = \"1\"\nVersoin = \"2\"\n",
+			errMsg:   "unknown field `gcp.Key.Versoin`",
+		},
+		{
+			fileName: "top-level.yml",
+			content:  "tpye: local\n",
+			errMsg:   "unknown field `tpye`",
+		},
+		{
+			fileName: "config.ini",
+			content:  "type=local",
+			errMsg:   "unsupported config file extension",
+		},
+	}
+
+	for _, tc := range tcs {
+		filePath := filepath.Join(dir, tc.fileName)
+		if err := ioutil.WriteFile(filePath, []byte(tc.content), 0600); err != nil {
+			panic(err)
+		}
+
+		_, err := LoadConfigFromFile(filePath)
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tc.errMsg) {
+			panic(fmt.Sprintf("%v: expected an error containing %q, got %v", tc.fileName, tc.errMsg, err))
+		}
+	}
+}
+
+func TestLoadMultiConfigFromFile(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "signers.yaml")
+	content := `signers:
+  - name: relayer
+    type: local
+    chainIDs: [80001]
+    labels:
+      role: relayer
+    local:
+      PrivateKey: "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+      ChainID: 1
+`
+	if err := ioutil.WriteFile(filePath, []byte(content), 0600); err != nil {
+		panic(err)
+	}
+
+	signers, err := NewKMSSignersFromConfigFile(filePath)
+	if err != nil {
+		panic(err)
+	}
+	expected := "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	if signers["relayer"].GetAddress().Hex() != expected {
+		panic(fmt.Sprintf("expected address %v, got %v", expected, signers["relayer"].GetAddress().Hex()))
+	}
+
+	content = strings.Replace(content, "labels:", "lables:", 1)
+	if err = ioutil.WriteFile(filePath, []byte(content), 0600); err != nil {
+		panic(err)
+	}
+	_, err = LoadMultiConfigFromFile(filePath)
+	if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), "unknown field `lables`") {
+		panic(fmt.Sprintf("expected an unknown field error, got %v", err))
+	}
+}
@@ -0,0 +1,32 @@
+package kms
+
+import "github.com/LampardNguyen234/evm-kms/common"
+
+// Sentinel errors returned by the KMSSigners, which can be checked via errors.Is. They are aliases of the
+// errors defined in the common package.
+var (
+	// ErrInvalidConfig indicates that the given config is invalid. It is not retryable.
+	ErrInvalidConfig = common.ErrInvalidConfig
+
+	// ErrKeyNotFound indicates that the key (or key version) does not exist. It is not retryable.
+	ErrKeyNotFound = common.ErrKeyNotFound
+
+	// ErrKeyDisabled indicates that the key exists but cannot be used, e.g. it is disabled or pending deletion.
+	ErrKeyDisabled = common.ErrKeyDisabled
+
+	// ErrWrongKeySpec indicates that the key is not a secp256k1 signing key. It is not retryable.
+	ErrWrongKeySpec = common.ErrWrongKeySpec
+
+	// ErrSignatureInvalid indicates that the KMS returned a signature which is malformed, corrupted or does not
+	// match the public key.
+	ErrSignatureInvalid = common.ErrSignatureInvalid
+
+	// ErrThrottled indicates that the request has been rejected by a rate limit or a quota. It is retryable.
+	ErrThrottled = common.ErrThrottled
+
+	// ErrPermissionDenied indicates that the credentials are missing, invalid or not allowed to use the key.
+	ErrPermissionDenied = common.ErrPermissionDenied
+)
+
+// KMSError is an error returned by a KMS operation, see common.KMSError.
+type KMSError = common.KMSError
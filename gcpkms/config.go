@@ -3,7 +3,7 @@ package gcpkms
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
 	"os"
 )
 
@@ -15,7 +15,8 @@ type Key struct {
 	// Name is the name of the key in the Keyring.
 	Name string `json:"Name"`
 
-	// Version is the of the current key.
+	// Version is the version of the current key. It is either a version ID (e.g, "1"), VersionLatestEnabled or
+	// VersionPrimary; the latter two are resolved to a version ID when the client is created.
 	Version string `json:"Version"`
 }
 
@@ -39,6 +40,29 @@ type Config struct {
 	// Leave this field empty if the environment varialbe `GOOGLE_APPLICATION_CREDENTIALS` has been set.
 	CredentialLocation string `json:"CredentialLocation,omitempty"`
 
+	// CredentialsJSON is the inline content of a credentials file: a service account key, or a workload identity
+	// federation external account config generated by `gcloud iam workload-identity-pools create-cred-config`.
+	// It is typically given as a `${ENV_VAR}` or `file://path` reference.
+	CredentialsJSON string `json:"CredentialsJSON,omitempty"`
+
+	// WorkloadIdentity is the workload identity federation external account to authenticate with, without any
+	// credentials file.
+	WorkloadIdentity *WorkloadIdentityConfig `json:"WorkloadIdentity,omitempty"`
+
+	// Impersonate is the service account impersonated with the above credentials (or the default credentials if none
+	// is given), if any.
+	Impersonate *ImpersonateConfig `json:"Impersonate,omitempty"`
+
+	// Endpoint is the address of the Cloud KMS gRPC endpoint (e.g, a Private Service Connect endpoint or a local
+	// emulator), in the form of "host:port".
+	//
+	// Leave this field empty to use the public endpoint.
+	Endpoint string `json:"Endpoint,omitempty"`
+
+	// Insecure disables both authentication and TLS, which is only meant to be used with a local emulator.
+	// It requires Endpoint to be set.
+	Insecure bool `json:"Insecure,omitempty"`
+
 	// Key is the detail of the GCP KMS key.
 	Key Key `json:"Key"`
 
@@ -46,6 +70,10 @@ type Config struct {
 	//
 	// See https://chainlist.org.
 	ChainID uint64 `json:"ChainID"`
+
+	// SkipKeyValidation skips the GetCryptoKeyVersion call made at construction to check the algorithm and state of
+	// the key version (e.g, if the credentials are only granted `roles/cloudkms.signerVerifier`).
+	SkipKeyValidation bool `json:"SkipKeyValidation,omitempty"`
 }
 
 // IsValid checks if a Config is valid.
@@ -62,12 +90,32 @@ func (cfg Config) IsValid() (bool, error) {
 		return false, fmt.Errorf("invalid Key")
 	}
 
+	if cfg.Insecure && cfg.Endpoint == "" {
+		return false, fmt.Errorf("Insecure requires an Endpoint")
+	}
+
+	if err := cfg.validateCredentials(); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
-// LoadConfigFromFile loads the config from the given config file.
+// cryptoKeyName returns the resource name of the CryptoKey.
+func (cfg Config) cryptoKeyName() string {
+	return fmt.Sprintf("projects/%s/locations/%s/keyRings/%s/cryptoKeys/%s",
+		cfg.ProjectID, cfg.LocationID, cfg.Key.Keyring, cfg.Key.Name)
+}
+
+// keyVersionName returns the resource name of the CryptoKeyVersion.
+func (cfg Config) keyVersionName() string {
+	return fmt.Sprintf("%s/cryptoKeyVersions/%s", cfg.cryptoKeyName(), cfg.Key.Version)
+}
+
+// LoadConfigFromFile loads the config from the given config file. String values can reference environment variables
+// (`${ENV_VAR}`) or files (`file://path`); see common.InterpolateJSON.
 func LoadConfigFromFile(filePath string) (*Config, error) {
-	f, err := ioutil.ReadFile(filePath)
+	f, err := common2.ReadConfigFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +126,7 @@ func LoadConfigFromFile(filePath string) (*Config, error) {
 		return nil, err
 	}
 
-	if cfg.CredentialLocation == "" {
+	if cfg.CredentialLocation == "" && cfg.CredentialsJSON == "" && cfg.WorkloadIdentity == nil && !cfg.Insecure {
 		cfg.CredentialLocation = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
 	}
 
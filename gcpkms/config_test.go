@@ -15,3 +15,116 @@ func TestLoadConfigFromFile(t *testing.T) {
 	jsb, _ := json.MarshalIndent(cfg, "", "\t")
 	fmt.Println(string(jsb))
 }
+
+func TestConfig_IsValid(t *testing.T) {
+	cfg := Config{
+		ProjectID:  "evm-kms",
+		LocationID: "global",
+		Key:        Key{Keyring: "keyring", Name: "key", Version: "1"},
+	}
+	if _, err := cfg.IsValid(); err != nil {
+		panic(err)
+	}
+
+	cfg.Insecure = true
+	if _, err := cfg.IsValid(); err == nil {
+		panic("expected an error with Insecure and no Endpoint")
+	}
+
+	cfg.Endpoint = "localhost:8080"
+	if _, err := cfg.IsValid(); err != nil {
+		panic(err)
+	}
+}
+
+func TestConfig_IsValid_Credentials(t *testing.T) {
+	baseCfg := Config{
+		ProjectID:  "evm-kms",
+		LocationID: "global",
+		Key:        Key{Keyring: "keyring", Name: "key", Version: "1"},
+	}
+	workloadIdentity := &WorkloadIdentityConfig{
+		Audience:         "//iam.googleapis.com/projects/123456/locations/global/workloadIdentityPools/pool/providers/eks",
+		CredentialSource: CredentialSourceConfig{File: "/var/run/secrets/tokens/gcp-token"},
+	}
+
+	tcs := []struct {
+		update  func(cfg *Config)
+		isValid bool
+	}{
+		{update: func(cfg *Config) { cfg.CredentialsJSON = `{"type": "service_account"}` }, isValid: true},
+		{update: func(cfg *Config) { cfg.WorkloadIdentity = workloadIdentity }, isValid: true},
+		{update: func(cfg *Config) {
+			cfg.WorkloadIdentity = workloadIdentity
+			cfg.Impersonate = &ImpersonateConfig{TargetPrincipal: "signer@evm-kms.iam.gserviceaccount.com"}
+		}, isValid: true},
+		{update: func(cfg *Config) {
+			cfg.Impersonate = &ImpersonateConfig{TargetPrincipal: "signer@evm-kms.iam.gserviceaccount.com",
+				Delegates: []string{"delegate@evm-kms.iam.gserviceaccount.com"}}
+		}, isValid: true},
+		{update: func(cfg *Config) { cfg.Impersonate = &ImpersonateConfig{} }, isValid: false},
+		{update: func(cfg *Config) {
+			cfg.CredentialLocation = "/path/to/credentials.json"
+			cfg.CredentialsJSON = `{"type": "service_account"}`
+		}, isValid: false},
+		{update: func(cfg *Config) { cfg.WorkloadIdentity = &WorkloadIdentityConfig{Audience: "audience"} }, isValid: false},
+		{update: func(cfg *Config) {
+			cfg.WorkloadIdentity = &WorkloadIdentityConfig{Audience: "audience",
+				CredentialSource: CredentialSourceConfig{URL: "http://localhost", Format: "json"}}
+		}, isValid: false},
+		{update: func(cfg *Config) {
+			cfg.Endpoint = "localhost:8080"
+			cfg.Insecure = true
+			cfg.CredentialsJSON = `{"type": "service_account"}`
+		}, isValid: false},
+	}
+
+	for i, tc := range tcs {
+		cfg := baseCfg
+		tc.update(&cfg)
+		if _, err := cfg.IsValid(); (err == nil) != tc.isValid {
+			panic(fmt.Sprintf("tc %v: expected isValid = %v, got %v", i, tc.isValid, err))
+		}
+	}
+}
+
+func TestWorkloadIdentityConfig_externalAccountJSON(t *testing.T) {
+	cfg := WorkloadIdentityConfig{
+		Audience:         "//iam.googleapis.com/projects/123456/locations/global/workloadIdentityPools/pool/providers/aws",
+		CredentialSource: CredentialSourceConfig{EnvironmentID: "aws1"},
+	}
+	data, err := cfg.externalAccountJSON()
+	if err != nil {
+		panic(err)
+	}
+
+	var externalAccount struct {
+		Type             string `json:"type"`
+		Audience         string `json:"audience"`
+		SubjectTokenType string `json:"subject_token_type"`
+		TokenURL         string `json:"token_url"`
+		CredentialSource struct {
+			EnvironmentID               string `json:"environment_id"`
+			URL                         string `json:"url"`
+			RegionalCredVerificationURL string `json:"regional_cred_verification_url"`
+		} `json:"credential_source"`
+	}
+	if err = json.Unmarshal(data, &externalAccount); err != nil {
+		panic(err)
+	}
+	if externalAccount.Type != "external_account" || externalAccount.Audience != cfg.Audience ||
+		externalAccount.SubjectTokenType != subjectTokenTypeAWS || externalAccount.TokenURL != defaultTokenURL {
+		panic(fmt.Sprintf("invalid external account %v", string(data)))
+	}
+	if externalAccount.CredentialSource.EnvironmentID != "aws1" ||
+		externalAccount.CredentialSource.URL != awsSecurityCredentialsURL ||
+		externalAccount.CredentialSource.RegionalCredVerificationURL != awsRegionalCredVerifyURL {
+		panic(fmt.Sprintf("invalid credential source %v", string(data)))
+	}
+
+	// the generated config is accepted by the client library
+	clientCfg := Config{WorkloadIdentity: &cfg, Impersonate: &ImpersonateConfig{TargetPrincipal: "signer@evm-kms.iam.gserviceaccount.com"}}
+	if _, err = clientCfg.clientOptions(); err != nil {
+		panic(err)
+	}
+}
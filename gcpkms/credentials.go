@@ -0,0 +1,256 @@
+package gcpkms
+
+import (
+	kms "cloud.google.com/go/kms/apiv1"
+	"context"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"strings"
+)
+
+const (
+	defaultTokenURL = "https://sts.googleapis.com/v1/token"
+
+	subjectTokenTypeJWT = "urn:ietf:params:oauth:token-type:jwt"
+	subjectTokenTypeAWS = "urn:ietf:params:aws:token-type:aws4_request"
+
+	awsEnvironmentID             = "aws1"
+	awsRegionURL                 = "http://169.254.169.254/latest/meta-data/placement/availability-zone"
+	awsSecurityCredentialsURL    = "http://169.254.169.254/latest/meta-data/iam/security-credentials"
+	awsRegionalCredVerifyURL     = "https://sts.{region}.amazonaws.com?Action=GetCallerIdentity&Version=2011-06-15"
+	credentialSourceFormatText   = "text"
+	credentialSourceFormatJSON   = "json"
+	externalAccountCredentialKey = "external_account"
+)
+
+// ImpersonateConfig consists of the information to impersonate a service account. The base credentials (given by
+// CredentialLocation, CredentialsJSON, WorkloadIdentity, or the default credentials) must be granted
+// `roles/iam.serviceAccountTokenCreator` on the target principal (or on the first delegate).
+type ImpersonateConfig struct {
+	// TargetPrincipal is the email address of the service account to impersonate.
+	TargetPrincipal string `json:"TargetPrincipal"`
+
+	// Delegates are the email addresses of the service accounts in the delegation chain, if any. Each service
+	// account must be granted `roles/iam.serviceAccountTokenCreator` on the next one.
+	Delegates []string `json:"Delegates,omitempty"`
+}
+
+// CredentialSourceConfig specifies where the external credentials of a WorkloadIdentityConfig are retrieved from.
+// Exactly one of File, URL and EnvironmentID must be set.
+type CredentialSourceConfig struct {
+	// File is the path of the file holding the subject token (e.g, a Kubernetes projected service account token).
+	File string `json:"File,omitempty"`
+
+	// URL is the URL of the local metadata server returning the subject token (e.g, on Azure).
+	URL string `json:"URL,omitempty"`
+
+	// Headers are the headers of the requests to URL.
+	Headers map[string]string `json:"Headers,omitempty"`
+
+	// Format is the format of the subject token: "text" (default) or "json".
+	Format string `json:"Format,omitempty"`
+
+	// SubjectTokenFieldName is the name of the field holding the subject token, required if Format is "json"
+	// (e.g, "access_token" on Azure).
+	SubjectTokenFieldName string `json:"SubjectTokenFieldName,omitempty"`
+
+	// EnvironmentID is "aws1" to use the AWS credentials of the EC2 instance or ECS task.
+	EnvironmentID string `json:"EnvironmentID,omitempty"`
+
+	// RegionURL is the URL to retrieve the AWS region from. It defaults to the EC2 instance metadata endpoint.
+	RegionURL string `json:"RegionURL,omitempty"`
+
+	// RegionalCredVerificationURL is the URL of the AWS GetCallerIdentity request. It defaults to the regional STS
+	// endpoint.
+	RegionalCredVerificationURL string `json:"RegionalCredVerificationURL,omitempty"`
+}
+
+// WorkloadIdentityConfig consists of the information of a workload identity federation external account, to use
+// the credentials of another cloud (AWS, Azure) or of an OIDC provider (e.g, a GKE or EKS cluster) without a
+// service account key.
+//
+// See https://cloud.google.com/iam/docs/workload-identity-federation.
+type WorkloadIdentityConfig struct {
+	// Audience is the full resource name of the workload identity pool provider.
+	//
+	// Example: "//iam.googleapis.com/projects/123456/locations/global/workloadIdentityPools/my-pool/providers/my-provider".
+	Audience string `json:"Audience"`
+
+	// SubjectTokenType is the type of the external token. It defaults to "urn:ietf:params:oauth:token-type:jwt", or
+	// to "urn:ietf:params:aws:token-type:aws4_request" for AWS.
+	SubjectTokenType string `json:"SubjectTokenType,omitempty"`
+
+	// TokenURL is the URL of the STS token exchange endpoint. It defaults to "https://sts.googleapis.com/v1/token".
+	TokenURL string `json:"TokenURL,omitempty"`
+
+	// ServiceAccountImpersonationURL is the URL to impersonate a service account with the federated token, if any.
+	//
+	// Example: "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/signer@evm-kms.iam.gserviceaccount.com:generateAccessToken".
+	ServiceAccountImpersonationURL string `json:"ServiceAccountImpersonationURL,omitempty"`
+
+	// CredentialSource specifies where the external credentials are retrieved from.
+	CredentialSource CredentialSourceConfig `json:"CredentialSource"`
+}
+
+// IsValid checks if a WorkloadIdentityConfig is valid.
+func (cfg WorkloadIdentityConfig) IsValid() (bool, error) {
+	if cfg.Audience == "" {
+		return false, fmt.Errorf("empty WorkloadIdentity.Audience")
+	}
+
+	source := cfg.CredentialSource
+	numSources := 0
+	for _, s := range []string{source.File, source.URL, source.EnvironmentID} {
+		if s != "" {
+			numSources++
+		}
+	}
+	if numSources != 1 {
+		return false, fmt.Errorf("exactly one of File, URL and EnvironmentID of WorkloadIdentity.CredentialSource is required")
+	}
+
+	if source.EnvironmentID != "" && source.EnvironmentID != awsEnvironmentID {
+		return false, fmt.Errorf("WorkloadIdentity.CredentialSource.EnvironmentID `%v` not supported", source.EnvironmentID)
+	}
+
+	switch strings.ToLower(source.Format) {
+	case "", credentialSourceFormatText:
+	case credentialSourceFormatJSON:
+		if source.SubjectTokenFieldName == "" {
+			return false, fmt.Errorf("WorkloadIdentity.CredentialSource.SubjectTokenFieldName is required for the json format")
+		}
+	default:
+		return false, fmt.Errorf("WorkloadIdentity.CredentialSource.Format `%v` not supported", source.Format)
+	}
+
+	return true, nil
+}
+
+// externalAccountJSON returns the external account credentials file of the WorkloadIdentityConfig, as generated by
+// `gcloud iam workload-identity-pools create-cred-config`.
+func (cfg WorkloadIdentityConfig) externalAccountJSON() ([]byte, error) {
+	source := cfg.CredentialSource
+	credentialSource := make(map[string]interface{})
+	subjectTokenType := subjectTokenTypeJWT
+
+	switch {
+	case source.EnvironmentID != "":
+		subjectTokenType = subjectTokenTypeAWS
+		credentialSource["environment_id"] = source.EnvironmentID
+		credentialSource["region_url"] = stringOrDefault(source.RegionURL, awsRegionURL)
+		credentialSource["url"] = awsSecurityCredentialsURL
+		credentialSource["regional_cred_verification_url"] = stringOrDefault(source.RegionalCredVerificationURL, awsRegionalCredVerifyURL)
+	case source.File != "":
+		credentialSource["file"] = source.File
+	default:
+		credentialSource["url"] = source.URL
+		if len(source.Headers) > 0 {
+			credentialSource["headers"] = source.Headers
+		}
+	}
+	if source.EnvironmentID == "" {
+		credentialSource["format"] = map[string]string{
+			"type":                     stringOrDefault(strings.ToLower(source.Format), credentialSourceFormatText),
+			"subject_token_field_name": source.SubjectTokenFieldName,
+		}
+	}
+
+	externalAccount := map[string]interface{}{
+		"type":               externalAccountCredentialKey,
+		"audience":           cfg.Audience,
+		"subject_token_type": stringOrDefault(cfg.SubjectTokenType, subjectTokenType),
+		"token_url":          stringOrDefault(cfg.TokenURL, defaultTokenURL),
+		"credential_source":  credentialSource,
+	}
+	if cfg.ServiceAccountImpersonationURL != "" {
+		externalAccount["service_account_impersonation_url"] = cfg.ServiceAccountImpersonationURL
+	}
+
+	return json.Marshal(externalAccount)
+}
+
+// validateCredentials checks that at most one source of base credentials is given, and that the impersonation and
+// workload identity configs (if any) are valid.
+func (cfg Config) validateCredentials() error {
+	numSources := 0
+	if cfg.CredentialLocation != "" {
+		numSources++
+	}
+	if cfg.CredentialsJSON != "" {
+		numSources++
+	}
+	if cfg.WorkloadIdentity != nil {
+		numSources++
+		if _, err := cfg.WorkloadIdentity.IsValid(); err != nil {
+			return err
+		}
+	}
+	if numSources > 1 {
+		return fmt.Errorf("at most one of CredentialLocation, CredentialsJSON and WorkloadIdentity is allowed")
+	}
+
+	if cfg.Impersonate != nil && cfg.Impersonate.TargetPrincipal == "" {
+		return fmt.Errorf("empty Impersonate.TargetPrincipal")
+	}
+
+	if cfg.Insecure && (numSources > 0 || cfg.Impersonate != nil) {
+		return fmt.Errorf("Insecure does not allow credentials")
+	}
+
+	return nil
+}
+
+// clientOptions returns the client options of the kms.KeyManagementClient specified by the config.
+func (cfg Config) clientOptions() ([]option.ClientOption, error) {
+	options := make([]option.ClientOption, 0)
+	if cfg.Endpoint != "" {
+		options = append(options, option.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		return append(options,
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials()))), nil
+	}
+
+	credentialsOptions := make([]option.ClientOption, 0)
+	switch {
+	case cfg.CredentialLocation != "":
+		credentialsOptions = append(credentialsOptions, option.WithCredentialsFile(cfg.CredentialLocation))
+	case cfg.CredentialsJSON != "":
+		credentialsOptions = append(credentialsOptions, option.WithCredentialsJSON([]byte(cfg.CredentialsJSON)))
+	case cfg.WorkloadIdentity != nil:
+		externalAccount, err := cfg.WorkloadIdentity.externalAccountJSON()
+		if err != nil {
+			return nil, fmt.Errorf("cannot build the external account credentials: %v", err)
+		}
+		credentialsOptions = append(credentialsOptions, option.WithCredentialsJSON(externalAccount))
+	}
+
+	if cfg.Impersonate != nil {
+		// the token source refreshes the tokens for the whole lifetime of the client, so it must not be bound to the
+		// context of the construction.
+		tokenSource, err := impersonate.CredentialsTokenSource(context.Background(), impersonate.CredentialsConfig{
+			TargetPrincipal: cfg.Impersonate.TargetPrincipal,
+			Delegates:       cfg.Impersonate.Delegates,
+			Scopes:          kms.DefaultAuthScopes(),
+		}, credentialsOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("cannot impersonate %v: %v", cfg.Impersonate.TargetPrincipal, err)
+		}
+		credentialsOptions = []option.ClientOption{option.WithTokenSource(tokenSource)}
+	}
+
+	return append(options, credentialsOptions...), nil
+}
+
+func stringOrDefault(s, defaultValue string) string {
+	if s == "" {
+		return defaultValue
+	}
+
+	return s
+}
@@ -0,0 +1,221 @@
+package gcpkms
+
+import (
+	kms "cloud.google.com/go/kms/apiv1"
+	"context"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+	"strings"
+)
+
+// KeyInfo describes a secp256k1 signing key version of the GCP KMS.
+type KeyInfo struct {
+	// Name is the resource name of the CryptoKeyVersion.
+	Name string `json:"Name"`
+
+	// Key is the detail of the key version, which can be used in a Config.
+	Key Key `json:"Key"`
+
+	// ProtectionLevel is the protection level of the key version (e.g, "HSM", "SOFTWARE").
+	ProtectionLevel string `json:"ProtectionLevel"`
+
+	// State is the state of the key version (e.g, "ENABLED", "DISABLED").
+	State string `json:"State"`
+
+	// Labels are the labels of the crypto key.
+	Labels map[string]string `json:"Labels,omitempty"`
+
+	// Address is the EVM address derived from the public key. It is only set for enabled key versions, as the public
+	// key of other versions cannot be retrieved.
+	Address common.Address `json:"Address"`
+}
+
+// ListKeys lists all the EC_SIGN_SECP256K1_SHA256 key versions in the project and location of the given config,
+// together with their labels and EVM addresses. Destroyed key versions are skipped. cfg.Key is ignored.
+//
+// It requires the `cloudkms.keyRings.list`, `cloudkms.cryptoKeys.list`, `cloudkms.cryptoKeyVersions.list` and
+// `cloudkms.cryptoKeyVersions.viewPublicKey` permissions (e.g, `roles/cloudkms.viewer` and
+// `roles/cloudkms.publicKeyViewer`).
+func ListKeys(ctx context.Context, cfg Config) ([]KeyInfo, error) {
+	options, err := cfg.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return ListKeysWithOptions(ctx, cfg, options)
+}
+
+// ListKeysWithOptions is the same as ListKeys, but the underlying kms.KeyManagementClient is created with the given
+// client options. Note that the credentials of cfg, cfg.Endpoint and cfg.Insecure are ignored.
+func ListKeysWithOptions(ctx context.Context, cfg Config, options []option.ClientOption) ([]KeyInfo, error) {
+	// the key is not needed to list keys
+	locationCfg := cfg
+	locationCfg.Key = Key{Keyring: "-", Name: "-", Version: "-"}
+	if _, err := locationCfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+
+	client, err := kms.NewKeyManagementClient(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	return listKeys(ctx, client, cfg)
+}
+
+// FindKeyByAddress returns the EC_SIGN_SECP256K1_SHA256 key version in the project and location of the given config,
+// whose EVM address is the given address. If no such key version exists, an error matching common.ErrKeyNotFound is
+// returned. cfg.Key is ignored.
+func FindKeyByAddress(ctx context.Context, cfg Config, address common.Address) (*KeyInfo, error) {
+	options, err := cfg.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return FindKeyByAddressWithOptions(ctx, cfg, options, address)
+}
+
+// FindKeyByAddressWithOptions is the same as FindKeyByAddress, but the underlying kms.KeyManagementClient is created
+// with the given client options. Note that the credentials of cfg, cfg.Endpoint and cfg.Insecure are ignored.
+func FindKeyByAddressWithOptions(ctx context.Context, cfg Config, options []option.ClientOption, address common.Address) (*KeyInfo, error) {
+	keys, err := ListKeysWithOptions(ctx, cfg, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return findKeyByAddress(keys, address)
+}
+
+// ListKeys lists all the EC_SIGN_SECP256K1_SHA256 key versions in the project and location of the GoogleKMSClient.
+// See ListKeys for more detail.
+func (c GoogleKMSClient) ListKeys(ctx context.Context) ([]KeyInfo, error) {
+	return listKeys(ctx, c.kmsClient, c.cfg)
+}
+
+// FindKeyByAddress returns the EC_SIGN_SECP256K1_SHA256 key version in the project and location of the
+// GoogleKMSClient, whose EVM address is the given address. See FindKeyByAddress for more detail.
+func (c GoogleKMSClient) FindKeyByAddress(ctx context.Context, address common.Address) (*KeyInfo, error) {
+	keys, err := c.ListKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return findKeyByAddress(keys, address)
+}
+
+// listKeys iterates over the key rings, crypto keys and key versions of the project and location of cfg.
+func listKeys(ctx context.Context, client *kms.KeyManagementClient, cfg Config) ([]KeyInfo, error) {
+	res := make([]KeyInfo, 0)
+
+	keyRingIt := client.ListKeyRings(ctx, &kmspb.ListKeyRingsRequest{
+		Parent: fmt.Sprintf("projects/%s/locations/%s", cfg.ProjectID, cfg.LocationID),
+	})
+	for {
+		keyRing, err := keyRingIt.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, wrapError("ListKeyRings", err)
+		}
+
+		cryptoKeyIt := client.ListCryptoKeys(ctx, &kmspb.ListCryptoKeysRequest{Parent: keyRing.Name})
+		for {
+			cryptoKey, err := cryptoKeyIt.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, wrapError("ListCryptoKeys", err)
+			}
+			if cryptoKey.Purpose != kmspb.CryptoKey_ASYMMETRIC_SIGN {
+				continue
+			}
+
+			keyInfos, err := listKeyVersions(ctx, client, cryptoKey)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, keyInfos...)
+		}
+	}
+
+	return res, nil
+}
+
+// listKeyVersions returns the secp256k1 key versions of the given crypto key, except for the destroyed ones.
+func listKeyVersions(ctx context.Context, client *kms.KeyManagementClient, cryptoKey *kmspb.CryptoKey) ([]KeyInfo, error) {
+	res := make([]KeyInfo, 0)
+
+	versionIt := client.ListCryptoKeyVersions(ctx, &kmspb.ListCryptoKeyVersionsRequest{Parent: cryptoKey.Name})
+	for {
+		version, err := versionIt.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, wrapError("ListCryptoKeyVersions", err)
+		}
+		if version.Algorithm != kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256 {
+			continue
+		}
+		if version.State == kmspb.CryptoKeyVersion_DESTROYED ||
+			version.State == kmspb.CryptoKeyVersion_DESTROY_SCHEDULED {
+			continue
+		}
+
+		keyInfo := KeyInfo{
+			Name:            version.Name,
+			Key:             parseKeyVersionName(version.Name),
+			ProtectionLevel: version.ProtectionLevel.String(),
+			State:           version.State.String(),
+			Labels:          cryptoKey.Labels,
+		}
+
+		if version.State == kmspb.CryptoKeyVersion_ENABLED {
+			kmsPubKey, err := client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{Name: version.Name})
+			if err != nil {
+				return nil, wrapError("GetPublicKey", err)
+			}
+			pubKey, err := parseKMSPublicKey(kmsPubKey)
+			if err != nil {
+				return nil, fmt.Errorf("invalid public key of key version %v: %w", version.Name, err)
+			}
+			keyInfo.Address = crypto.PubkeyToAddress(*pubKey)
+		}
+
+		res = append(res, keyInfo)
+	}
+
+	return res, nil
+}
+
+// findKeyByAddress returns the key with the given address among the given keys.
+func findKeyByAddress(keys []KeyInfo, address common.Address) (*KeyInfo, error) {
+	for _, keyInfo := range keys {
+		if keyInfo.Address == address {
+			return &keyInfo, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no key version with address %v", common2.ErrKeyNotFound, address)
+}
+
+// parseKeyVersionName parses the resource name of a CryptoKeyVersion
+// (projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*) into a Key.
+func parseKeyVersionName(name string) Key {
+	parts := strings.Split(name, "/")
+	if len(parts) != 10 {
+		return Key{}
+	}
+
+	return Key{Keyring: parts[5], Name: parts[7], Version: parts[9]}
+}
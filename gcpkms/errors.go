@@ -0,0 +1,42 @@
+package gcpkms
+
+import (
+	"errors"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// wrapError wraps an error returned by the GCP KMS client into a common2.KMSError, whose Kind is derived from the
+// gRPC status code.
+func wrapError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return common2.NewKMSError(op, errorKind(err), err)
+}
+
+// errorKind maps an error returned by the GCP KMS client to one of the sentinel errors of the common package. The gRPC
+// status is looked up in the whole chain of wrapped errors, as status.Code only checks the error itself.
+func errorKind(err error) error {
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &grpcErr) {
+		return nil
+	}
+
+	switch grpcErr.GRPCStatus().Code() {
+	case codes.NotFound:
+		return common2.ErrKeyNotFound
+	case codes.FailedPrecondition:
+		return common2.ErrKeyDisabled
+	case codes.ResourceExhausted:
+		return common2.ErrThrottled
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return common2.ErrPermissionDenied
+	case codes.InvalidArgument, codes.AlreadyExists:
+		return common2.ErrInvalidConfig
+	}
+
+	return nil
+}
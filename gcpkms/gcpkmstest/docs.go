@@ -0,0 +1,6 @@
+// Package gcpkmstest provides a fake GCP Cloud KMS server for testing code that depends on the gcpkms package, without
+// any GCP project or network access.
+//
+// The Server is an in-process gRPC KeyManagementService backed by in-memory keys. Only the operations needed by the
+// gcpkms package are implemented; the others return codes.Unimplemented.
+package gcpkmstest
@@ -0,0 +1,597 @@
+package gcpkmstest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/LampardNguyen234/evm-kms/gcpkms"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/api/option"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"hash/crc32"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// ProjectID is the default project ID used by NewKey.
+	ProjectID = "test-project"
+
+	// LocationID is the default location ID used by NewKey.
+	LocationID = "global"
+
+	// KeyRing is the default key ring used by NewKey.
+	KeyRing = "test-keyring"
+)
+
+// Fault is a fault injected by the Server into AsymmetricSign responses.
+type Fault int
+
+const (
+	// FaultNone makes the Server behave normally.
+	FaultNone Fault = iota
+
+	// FaultUnverifiedDigest makes the Server report that the digest CRC32C was not verified.
+	FaultUnverifiedDigest
+
+	// FaultCorruptSignature makes the Server return a signature CRC32C which does not match the signature.
+	FaultCorruptSignature
+)
+
+// cryptoKeyVersion is an in-memory CryptoKeyVersion.
+type cryptoKeyVersion struct {
+	version    *kmspb.CryptoKeyVersion
+	privateKey *ecdsa.PrivateKey
+}
+
+// cryptoKey is an in-memory CryptoKey.
+type cryptoKey struct {
+	key      *kmspb.CryptoKey
+	versions []*cryptoKeyVersion
+}
+
+// Server is a fake GCP Cloud KMS server, backed by in-memory keys.
+type Server struct {
+	kmspb.UnimplementedKeyManagementServiceServer
+
+	// Addr is the address the Server is listening on.
+	Addr string
+
+	grpcServer *grpc.Server
+	mtx        sync.RWMutex
+	keyRings   map[string]*kmspb.KeyRing
+	cryptoKeys map[string]*cryptoKey
+	counter    int
+	fault      Fault
+	err        error
+}
+
+// NewServer starts and returns a new Server listening on a local port. The caller should call Close when finished,
+// to shut it down.
+func NewServer() (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen: %v", err)
+	}
+
+	s := &Server{
+		Addr:       l.Addr().String(),
+		keyRings:   make(map[string]*kmspb.KeyRing),
+		cryptoKeys: make(map[string]*cryptoKey),
+	}
+	s.grpcServer = grpc.NewServer(grpc.UnaryInterceptor(s.intercept))
+	kmspb.RegisterKeyManagementServiceServer(s.grpcServer, s)
+	go func() {
+		_ = s.grpcServer.Serve(l)
+	}()
+
+	return s, nil
+}
+
+// Close shuts down the Server.
+func (s *Server) Close() {
+	s.grpcServer.Stop()
+}
+
+// ClientOptions returns the client options to connect a kms.KeyManagementClient to the Server.
+func (s *Server) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(s.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}
+}
+
+// NewGoogleKMSClient creates a gcpkms.GoogleKMSClient connected to the Server with the given config. If cfg.ProjectID
+// is empty, a new key is created.
+func (s *Server) NewGoogleKMSClient(ctx context.Context, cfg gcpkms.Config, txSigner ...types.Signer) (*gcpkms.GoogleKMSClient, error) {
+	if cfg.ProjectID == "" {
+		chainID := cfg.ChainID
+		cfg = s.NewKey()
+		cfg.ChainID = chainID
+	}
+
+	return gcpkms.NewGoogleKMSClientWithOptions(ctx, cfg, s.ClientOptions(), txSigner...)
+}
+
+// NewKey creates a new enabled secp256k1 signing key in the default key ring and returns its config.
+func (s *Server) NewKey() gcpkms.Config {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+
+	s.mtx.Lock()
+	s.counter++
+	cfg := gcpkms.Config{
+		ProjectID:  ProjectID,
+		LocationID: LocationID,
+		Key: gcpkms.Key{
+			Keyring: KeyRing,
+			Name:    fmt.Sprintf("key-%v", s.counter),
+			Version: "1",
+		},
+	}
+	s.mtx.Unlock()
+
+	s.AddKey(cfg, privateKey)
+
+	return cfg
+}
+
+// AddKey adds the given private key as an enabled signing key version at the path given by cfg, creating the key
+// ring and the crypto key if needed. The algorithm is derived from the curve of the private key (secp256k1 or P-256).
+func (s *Server) AddKey(cfg gcpkms.Config, privateKey *ecdsa.PrivateKey) {
+	algorithm := kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256
+	if privateKey.Curve == elliptic.P256() {
+		algorithm = kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256
+	}
+
+	keyRingName := fmt.Sprintf("projects/%s/locations/%s/keyRings/%s", cfg.ProjectID, cfg.LocationID, cfg.Key.Keyring)
+	keyName := fmt.Sprintf("%s/cryptoKeys/%s", keyRingName, cfg.Key.Name)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.keyRings[keyRingName]; !ok {
+		s.keyRings[keyRingName] = &kmspb.KeyRing{Name: keyRingName, CreateTime: timestamppb.Now()}
+	}
+	k, ok := s.cryptoKeys[keyName]
+	if !ok {
+		k = &cryptoKey{key: &kmspb.CryptoKey{
+			Name:            keyName,
+			Purpose:         kmspb.CryptoKey_ASYMMETRIC_SIGN,
+			CreateTime:      timestamppb.Now(),
+			VersionTemplate: &kmspb.CryptoKeyVersionTemplate{ProtectionLevel: kmspb.ProtectionLevel_SOFTWARE, Algorithm: algorithm},
+		}}
+		s.cryptoKeys[keyName] = k
+	}
+
+	k.versions = append(k.versions, &cryptoKeyVersion{
+		version:    newVersion(fmt.Sprintf("%s/cryptoKeyVersions/%s", keyName, cfg.Key.Version), algorithm, kmspb.ProtectionLevel_SOFTWARE),
+		privateKey: privateKey,
+	})
+}
+
+// SetFault injects the given fault into subsequent AsymmetricSign responses.
+func (s *Server) SetFault(fault Fault) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.fault = fault
+}
+
+// SetError makes all subsequent calls fail with a status error of the given code, until it is called with codes.OK.
+func (s *Server) SetError(code codes.Code) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.err = nil
+	if code != codes.OK {
+		s.err = status.Errorf(code, "injected error: %v", code)
+	}
+}
+
+// intercept returns the injected error, if any, before handling a call.
+func (s *Server) intercept(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	s.mtx.RLock()
+	err := s.err
+	s.mtx.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// SetKeyVersionState sets the state of the key version at the path given by cfg.
+func (s *Server) SetKeyVersionState(cfg gcpkms.Config, state kmspb.CryptoKeyVersion_CryptoKeyVersionState) error {
+	v, err := s.getVersion(fmt.Sprintf("projects/%s/locations/%s/keyRings/%s/cryptoKeys/%s/cryptoKeyVersions/%s",
+		cfg.ProjectID, cfg.LocationID, cfg.Key.Keyring, cfg.Key.Name, cfg.Key.Version))
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	v.version.State = state
+
+	return nil
+}
+
+// GetCryptoKeyVersion implements kmspb.KeyManagementServiceServer. A version pending generation is enabled once
+// it has been returned.
+func (s *Server) GetCryptoKeyVersion(_ context.Context, req *kmspb.GetCryptoKeyVersionRequest) (*kmspb.CryptoKeyVersion, error) {
+	v, err := s.getVersion(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ret := proto.Clone(v.version).(*kmspb.CryptoKeyVersion)
+	if v.version.State == kmspb.CryptoKeyVersion_PENDING_GENERATION {
+		v.version.State = kmspb.CryptoKeyVersion_ENABLED
+	}
+
+	return ret, nil
+}
+
+// GetPublicKey implements kmspb.KeyManagementServiceServer.
+func (s *Server) GetPublicKey(_ context.Context, req *kmspb.GetPublicKeyRequest) (*kmspb.PublicKey, error) {
+	v, err := s.getEnabledVersion(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var der []byte
+	if v.version.Algorithm == kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256 {
+		der, err = common2.MarshalPKIXPublicKey(&v.privateKey.PublicKey)
+	} else {
+		der, err = x509.MarshalPKIXPublicKey(&v.privateKey.PublicKey)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	return &kmspb.PublicKey{
+		Name:            v.version.Name,
+		Pem:             pemKey,
+		PemCrc32C:       wrapperspb.Int64(int64(crc32c([]byte(pemKey)))),
+		Algorithm:       v.version.Algorithm,
+		ProtectionLevel: v.version.ProtectionLevel,
+	}, nil
+}
+
+// AsymmetricSign implements kmspb.KeyManagementServiceServer.
+func (s *Server) AsymmetricSign(_ context.Context, req *kmspb.AsymmetricSignRequest) (*kmspb.AsymmetricSignResponse, error) {
+	v, err := s.getEnabledVersion(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := req.GetDigest().GetSha256()
+	if len(digest) != 32 {
+		return nil, status.Error(codes.InvalidArgument, "only SHA256 digests are supported")
+	}
+	if req.DigestCrc32C != nil && req.DigestCrc32C.Value != int64(crc32c(digest)) {
+		return nil, status.Error(codes.InvalidArgument, "digest_crc32c does not match the digest")
+	}
+
+	var der []byte
+	if v.version.Algorithm == kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256 {
+		sig, err := crypto.Sign(digest, v.privateKey)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		// the real KMS does not enforce a low S, so half of the signatures are returned in their high-S form.
+		r := new(big.Int).SetBytes(sig[:32])
+		sVal := new(big.Int).SetBytes(sig[32:64])
+		if digest[0]%2 == 1 {
+			sVal = new(big.Int).Sub(common2.CurveOrder, sVal)
+		}
+		der, err = asn1.Marshal(common2.KmsSignature{R: r, S: sVal})
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	} else {
+		der, err = ecdsa.SignASN1(rand.Reader, v.privateKey, digest)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	s.mtx.RLock()
+	fault := s.fault
+	s.mtx.RUnlock()
+
+	resp := &kmspb.AsymmetricSignResponse{
+		Name:                 v.version.Name,
+		Signature:            der,
+		SignatureCrc32C:      wrapperspb.Int64(int64(crc32c(der))),
+		VerifiedDigestCrc32C: req.DigestCrc32C != nil,
+		ProtectionLevel:      v.version.ProtectionLevel,
+	}
+	switch fault {
+	case FaultUnverifiedDigest:
+		resp.VerifiedDigestCrc32C = false
+	case FaultCorruptSignature:
+		resp.SignatureCrc32C = wrapperspb.Int64(resp.SignatureCrc32C.Value ^ 1)
+	}
+
+	return resp, nil
+}
+
+// ListKeyRings implements kmspb.KeyManagementServiceServer. Pagination is not supported.
+func (s *Server) ListKeyRings(_ context.Context, req *kmspb.ListKeyRingsRequest) (*kmspb.ListKeyRingsResponse, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	resp := &kmspb.ListKeyRingsResponse{}
+	for name, keyRing := range s.keyRings {
+		if strings.HasPrefix(name, req.Parent+"/keyRings/") {
+			resp.KeyRings = append(resp.KeyRings, proto.Clone(keyRing).(*kmspb.KeyRing))
+		}
+	}
+	sort.Slice(resp.KeyRings, func(i, j int) bool {
+		return resp.KeyRings[i].Name < resp.KeyRings[j].Name
+	})
+	resp.TotalSize = int32(len(resp.KeyRings))
+
+	return resp, nil
+}
+
+// ListCryptoKeys implements kmspb.KeyManagementServiceServer. All crypto keys are returned in a single page.
+func (s *Server) ListCryptoKeys(_ context.Context, req *kmspb.ListCryptoKeysRequest) (*kmspb.ListCryptoKeysResponse, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if _, ok := s.keyRings[req.Parent]; !ok {
+		return nil, status.Errorf(codes.NotFound, "KeyRing %v not found", req.Parent)
+	}
+
+	resp := &kmspb.ListCryptoKeysResponse{}
+	for name, k := range s.cryptoKeys {
+		if strings.HasPrefix(name, req.Parent+"/cryptoKeys/") {
+			resp.CryptoKeys = append(resp.CryptoKeys, proto.Clone(k.key).(*kmspb.CryptoKey))
+		}
+	}
+	sort.Slice(resp.CryptoKeys, func(i, j int) bool {
+		return resp.CryptoKeys[i].Name < resp.CryptoKeys[j].Name
+	})
+	resp.TotalSize = int32(len(resp.CryptoKeys))
+
+	return resp, nil
+}
+
+// ListCryptoKeyVersions implements kmspb.KeyManagementServiceServer. All versions are returned in a single page.
+func (s *Server) ListCryptoKeyVersions(_ context.Context, req *kmspb.ListCryptoKeyVersionsRequest) (*kmspb.ListCryptoKeyVersionsResponse, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	k, ok := s.cryptoKeys[req.Parent]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "CryptoKey %v not found", req.Parent)
+	}
+
+	resp := &kmspb.ListCryptoKeyVersionsResponse{}
+	for _, v := range k.versions {
+		resp.CryptoKeyVersions = append(resp.CryptoKeyVersions, proto.Clone(v.version).(*kmspb.CryptoKeyVersion))
+	}
+	resp.TotalSize = int32(len(resp.CryptoKeyVersions))
+
+	return resp, nil
+}
+
+// GetCryptoKey implements kmspb.KeyManagementServiceServer. As in Cloud KMS, asymmetric keys have no primary version.
+func (s *Server) GetCryptoKey(_ context.Context, req *kmspb.GetCryptoKeyRequest) (*kmspb.CryptoKey, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	k, ok := s.cryptoKeys[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "CryptoKey %v not found", req.Name)
+	}
+
+	return proto.Clone(k.key).(*kmspb.CryptoKey), nil
+}
+
+// CreateCryptoKeyVersion implements kmspb.KeyManagementServiceServer. The new version uses the version template of
+// the crypto key. Software versions are enabled immediately, while HSM versions are pending generation until they
+// are observed once via GetCryptoKeyVersion.
+func (s *Server) CreateCryptoKeyVersion(_ context.Context, req *kmspb.CreateCryptoKeyVersionRequest) (*kmspb.CryptoKeyVersion, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	k, ok := s.cryptoKeys[req.Parent]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "CryptoKey %v not found", req.Parent)
+	}
+
+	v, err := addVersion(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Clone(v.version).(*kmspb.CryptoKeyVersion), nil
+}
+
+// GetKeyRing implements kmspb.KeyManagementServiceServer.
+func (s *Server) GetKeyRing(_ context.Context, req *kmspb.GetKeyRingRequest) (*kmspb.KeyRing, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	keyRing, ok := s.keyRings[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "KeyRing %v not found", req.Name)
+	}
+
+	return proto.Clone(keyRing).(*kmspb.KeyRing), nil
+}
+
+// CreateKeyRing implements kmspb.KeyManagementServiceServer.
+func (s *Server) CreateKeyRing(_ context.Context, req *kmspb.CreateKeyRingRequest) (*kmspb.KeyRing, error) {
+	if req.KeyRingId == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty key_ring_id")
+	}
+	name := fmt.Sprintf("%s/keyRings/%s", req.Parent, req.KeyRingId)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.keyRings[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "KeyRing %v already exists", name)
+	}
+	s.keyRings[name] = &kmspb.KeyRing{Name: name, CreateTime: timestamppb.Now()}
+
+	return proto.Clone(s.keyRings[name]).(*kmspb.KeyRing), nil
+}
+
+// CreateCryptoKey implements kmspb.KeyManagementServiceServer. Only ASYMMETRIC_SIGN crypto keys are supported, and
+// their first version is created along with them (see CreateCryptoKeyVersion).
+func (s *Server) CreateCryptoKey(_ context.Context, req *kmspb.CreateCryptoKeyRequest) (*kmspb.CryptoKey, error) {
+	if req.CryptoKeyId == "" || req.CryptoKey == nil {
+		return nil, status.Error(codes.InvalidArgument, "empty crypto_key_id or crypto_key")
+	}
+	if req.CryptoKey.Purpose != kmspb.CryptoKey_ASYMMETRIC_SIGN {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported purpose %v", req.CryptoKey.Purpose)
+	}
+	name := fmt.Sprintf("%s/cryptoKeys/%s", req.Parent, req.CryptoKeyId)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.keyRings[req.Parent]; !ok {
+		return nil, status.Errorf(codes.NotFound, "KeyRing %v not found", req.Parent)
+	}
+	if _, ok := s.cryptoKeys[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "CryptoKey %v already exists", name)
+	}
+
+	key := proto.Clone(req.CryptoKey).(*kmspb.CryptoKey)
+	key.Name = name
+	key.CreateTime = timestamppb.Now()
+	if key.VersionTemplate == nil {
+		key.VersionTemplate = &kmspb.CryptoKeyVersionTemplate{}
+	}
+	if key.VersionTemplate.ProtectionLevel == kmspb.ProtectionLevel_PROTECTION_LEVEL_UNSPECIFIED {
+		key.VersionTemplate.ProtectionLevel = kmspb.ProtectionLevel_SOFTWARE
+	}
+
+	k := &cryptoKey{key: key}
+	if !req.SkipInitialVersionCreation {
+		if _, err := addVersion(k); err != nil {
+			return nil, err
+		}
+	}
+	s.cryptoKeys[name] = k
+
+	return proto.Clone(key).(*kmspb.CryptoKey), nil
+}
+
+// addVersion generates a new version of the given crypto key, using its version template. The caller must hold the
+// lock of the Server.
+func addVersion(k *cryptoKey) (*cryptoKeyVersion, error) {
+	algorithm := k.key.VersionTemplate.GetAlgorithm()
+	var privateKey *ecdsa.PrivateKey
+	var err error
+	switch algorithm {
+	case kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256:
+		privateKey, err = crypto.GenerateKey()
+	case kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported algorithm %v", algorithm)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	v := &cryptoKeyVersion{
+		version: newVersion(fmt.Sprintf("%s/cryptoKeyVersions/%d", k.key.Name, len(k.versions)+1),
+			algorithm, k.key.VersionTemplate.GetProtectionLevel()),
+		privateKey: privateKey,
+	}
+	k.versions = append(k.versions, v)
+
+	return v, nil
+}
+
+// getEnabledVersion returns the enabled crypto key version with the given resource name.
+func (s *Server) getEnabledVersion(name string) (*cryptoKeyVersion, error) {
+	v, err := s.getVersion(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if v.version.State != kmspb.CryptoKeyVersion_ENABLED {
+		return nil, status.Errorf(codes.FailedPrecondition, "%v is not enabled, current state is: %v", name, v.version.State)
+	}
+
+	return v, nil
+}
+
+// getVersion returns the crypto key version with the given resource name.
+func (s *Server) getVersion(name string) (*cryptoKeyVersion, error) {
+	i := strings.Index(name, "/cryptoKeyVersions/")
+	if i < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid CryptoKeyVersion name %v", name)
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if k, ok := s.cryptoKeys[name[:i]]; ok {
+		for _, v := range k.versions {
+			if v.version.Name == name {
+				return v, nil
+			}
+		}
+	}
+
+	return nil, status.Errorf(codes.NotFound, "CryptoKeyVersion %v not found", name)
+}
+
+func newVersion(name string, algorithm kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm,
+	protectionLevel kmspb.ProtectionLevel,
+) *kmspb.CryptoKeyVersion {
+	state := kmspb.CryptoKeyVersion_ENABLED
+	if protectionLevel == kmspb.ProtectionLevel_HSM {
+		state = kmspb.CryptoKeyVersion_PENDING_GENERATION
+	}
+
+	return &kmspb.CryptoKeyVersion{
+		Name:            name,
+		State:           state,
+		ProtectionLevel: protectionLevel,
+		Algorithm:       algorithm,
+		CreateTime:      timestamppb.Now(),
+		GenerateTime:    timestamppb.Now(),
+	}
+}
+
+func crc32c(data []byte) uint32 {
+	return crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+}
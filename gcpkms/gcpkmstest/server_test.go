@@ -0,0 +1,598 @@
+package gcpkmstest
+
+import (
+	kms "cloud.google.com/go/kms/apiv1"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	evmkms "github.com/LampardNguyen234/evm-kms"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/LampardNguyen234/evm-kms/gcpkms"
+	"github.com/LampardNguyen234/evm-kms/kmstest"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/api/iterator"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+var receiverAddr = common.HexToAddress("0x243e9517a24813a2d73e9a74cd2c1c699d0ff7a5")
+
+func TestServer_SignHash(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	cfg := gcpkms.Config{
+		ProjectID:  ProjectID,
+		LocationID: LocationID,
+		Key:        gcpkms.Key{Keyring: KeyRing, Name: "my-key", Version: "1"},
+		ChainID:    80001,
+	}
+	s.AddKey(cfg, privateKey)
+
+	c, err := s.NewGoogleKMSClient(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+	if c.GetAddress() != crypto.PubkeyToAddress(privateKey.PublicKey) {
+		panic("invalid address")
+	}
+
+	for i := 0; i < 10; i++ {
+		digest := crypto.Keccak256Hash([]byte{byte(i)})
+		sig, err := c.SignHash(digest)
+		if err != nil {
+			panic(err)
+		}
+
+		pubKey, err := crypto.SigToPub(digest[:], sig)
+		if err != nil {
+			panic(err)
+		}
+		if crypto.PubkeyToAddress(*pubKey) != c.GetAddress() {
+			panic("invalid signature")
+		}
+	}
+}
+
+func TestServer_Fault(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	c, err := s.NewGoogleKMSClient(context.Background(), gcpkms.Config{ChainID: 80001})
+	if err != nil {
+		panic(err)
+	}
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+
+	for _, fault := range []Fault{FaultUnverifiedDigest, FaultCorruptSignature} {
+		s.SetFault(fault)
+		if _, err = c.SignHash(digest); err == nil {
+			panic(fmt.Sprintf("expected an error with fault %v", fault))
+		}
+	}
+
+	s.SetFault(FaultNone)
+	if _, err = c.SignHash(digest); err != nil {
+		panic(err)
+	}
+}
+
+func TestServer_GetEVMSignerFn(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	c, err := s.NewGoogleKMSClient(context.Background(), gcpkms.Config{ChainID: 80001})
+	if err != nil {
+		panic(err)
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(80001),
+		To:        &receiverAddr,
+		Nonce:     1,
+		GasTipCap: big.NewInt(1000000),
+		GasFeeCap: big.NewInt(2000000),
+		Gas:       50000,
+		Value:     big.NewInt(100),
+	})
+	signedTx, err := c.GetEVMSignerFn()(c.GetAddress(), tx)
+	if err != nil {
+		panic(err)
+	}
+
+	signed, err := c.HasSignedTx(signedTx)
+	if err != nil {
+		panic(err)
+	}
+	if !signed {
+		panic("transaction not signed by the client")
+	}
+
+	if _, err = c.GetEVMSignerFn()(receiverAddr, tx); err != bind.ErrNotAuthorized {
+		panic("expected bind.ErrNotAuthorized")
+	}
+}
+
+func TestServer_UnknownKey(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	cfg := s.NewKey()
+	cfg.Key.Version = "2"
+	if _, err = s.NewGoogleKMSClient(context.Background(), cfg); err == nil {
+		panic("expected an error with an unknown key version")
+	}
+}
+
+func TestServer_CreateCryptoKeyVersion(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	cfg := s.NewKey()
+	kmsClient, err := kms.NewKeyManagementClient(ctx, s.ClientOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	defer kmsClient.Close()
+
+	version, err := kmsClient.CreateCryptoKeyVersion(ctx, &kmspb.CreateCryptoKeyVersionRequest{
+		Parent: fmt.Sprintf("projects/%s/locations/%s/keyRings/%s/cryptoKeys/%s",
+			cfg.ProjectID, cfg.LocationID, cfg.Key.Keyring, cfg.Key.Name),
+	})
+	if err != nil {
+		panic(err)
+	}
+	if version.State != kmspb.CryptoKeyVersion_ENABLED ||
+		version.Algorithm != kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256 {
+		panic("invalid key version")
+	}
+
+	c1, err := s.NewGoogleKMSClient(ctx, cfg)
+	if err != nil {
+		panic(err)
+	}
+	cfg.Key.Version = "2"
+	c2, err := s.NewGoogleKMSClient(ctx, cfg)
+	if err != nil {
+		panic(err)
+	}
+	if c1.GetAddress() == c2.GetAddress() {
+		panic("expected different addresses for different key versions")
+	}
+
+	it := kmsClient.ListKeyRings(ctx, &kmspb.ListKeyRingsRequest{
+		Parent: fmt.Sprintf("projects/%s/locations/%s", cfg.ProjectID, cfg.LocationID),
+	})
+	numKeyRings := 0
+	for {
+		_, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			panic(err)
+		}
+		numKeyRings++
+	}
+	if numKeyRings != 1 {
+		panic(fmt.Sprintf("expected 1 key ring, got %v", numKeyRings))
+	}
+}
+
+func TestServer_Conformance(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	kmstest.RunConformance(t, func(t *testing.T) evmkms.KMSSigner {
+		c, err := s.NewGoogleKMSClient(context.Background(), gcpkms.Config{ChainID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return c
+	})
+}
+
+func TestServer_Endpoint(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	cfg := s.NewKey()
+	cfg.ChainID = 80001
+	cfg.Endpoint = s.Addr
+	cfg.Insecure = true
+	c, err := gcpkms.NewGoogleKMSClient(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+	if _, err = c.SignHash(digest); err != nil {
+		panic(err)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	cfg := s.NewKey()
+	cfg.Key.Version = "2"
+	if _, err = s.NewGoogleKMSClient(ctx, cfg); !errors.Is(err, common2.ErrKeyNotFound) {
+		panic(fmt.Sprintf("expected ErrKeyNotFound, got %v", err))
+	} else {
+		var grpcErr interface{ GRPCStatus() *status.Status }
+		if !errors.As(err, &grpcErr) || grpcErr.GRPCStatus().Code() != codes.NotFound {
+			panic(fmt.Sprintf("expected the gRPC status to be preserved, got %v", err))
+		}
+	}
+
+	cfg.ProjectID = ""
+	if _, err = gcpkms.NewGoogleKMSClientWithOptions(ctx, cfg, s.ClientOptions()); !errors.Is(err, common2.ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig, got %v", err))
+	}
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	cfg = gcpkms.Config{
+		ProjectID:  ProjectID,
+		LocationID: LocationID,
+		Key:        gcpkms.Key{Keyring: KeyRing, Name: "p256-key", Version: "1"},
+	}
+	s.AddKey(cfg, p256Key)
+	if _, err = s.NewGoogleKMSClient(ctx, cfg); !errors.Is(err, common2.ErrWrongKeySpec) {
+		panic(fmt.Sprintf("expected ErrWrongKeySpec, got %v", err))
+	}
+
+	c, err := s.NewGoogleKMSClient(ctx, gcpkms.Config{ChainID: 80001})
+	if err != nil {
+		panic(err)
+	}
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+
+	s.SetFault(FaultCorruptSignature)
+	if _, err = c.SignHash(digest); !errors.Is(err, common2.ErrSignatureInvalid) {
+		panic(fmt.Sprintf("expected ErrSignatureInvalid, got %v", err))
+	}
+	s.SetFault(FaultNone)
+
+	tcs := map[codes.Code]error{
+		codes.ResourceExhausted:  common2.ErrThrottled,
+		codes.PermissionDenied:   common2.ErrPermissionDenied,
+		codes.Unauthenticated:    common2.ErrPermissionDenied,
+		codes.FailedPrecondition: common2.ErrKeyDisabled,
+	}
+	for code, expected := range tcs {
+		s.SetError(code)
+
+		_, err = c.SignHash(digest)
+		if !errors.Is(err, expected) {
+			panic(fmt.Sprintf("%v: expected %v, got %v", code, expected, err))
+		}
+
+		_, err = c.GetEVMSignerFn()(c.GetAddress(), types.NewTx(&types.LegacyTx{To: &receiverAddr}))
+		if !errors.Is(err, expected) {
+			panic(fmt.Sprintf("%v: expected %v from the SignerFn, got %v", code, expected, err))
+		}
+	}
+}
+
+func TestServer_KeyValidation(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	cfg := s.NewKey()
+	if _, err = s.NewGoogleKMSClient(ctx, cfg); err != nil {
+		panic(err)
+	}
+
+	for _, state := range []kmspb.CryptoKeyVersion_CryptoKeyVersionState{
+		kmspb.CryptoKeyVersion_DISABLED,
+		kmspb.CryptoKeyVersion_DESTROY_SCHEDULED,
+	} {
+		if err = s.SetKeyVersionState(cfg, state); err != nil {
+			panic(err)
+		}
+		if _, err = s.NewGoogleKMSClient(ctx, cfg); !errors.Is(err, common2.ErrKeyDisabled) {
+			panic(fmt.Sprintf("%v: expected ErrKeyDisabled, got %v", state, err))
+		}
+	}
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	cfg = gcpkms.Config{
+		ProjectID:  ProjectID,
+		LocationID: LocationID,
+		Key:        gcpkms.Key{Keyring: KeyRing, Name: "p256-key", Version: "1"},
+	}
+	s.AddKey(cfg, p256Key)
+	if _, err = s.NewGoogleKMSClient(ctx, cfg); !errors.Is(err, common2.ErrWrongKeySpec) {
+		panic(fmt.Sprintf("expected ErrWrongKeySpec, got %v", err))
+	}
+
+	// the algorithm is still checked via GetPublicKey when the validation is skipped
+	cfg.SkipKeyValidation = true
+	if _, err = s.NewGoogleKMSClient(ctx, cfg); !errors.Is(err, common2.ErrWrongKeySpec) {
+		panic(fmt.Sprintf("expected ErrWrongKeySpec, got %v", err))
+	}
+}
+
+func TestServer_ProvisionKey(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	cfg := gcpkms.CreateKeyConfig{
+		Config: gcpkms.Config{
+			ProjectID:  ProjectID,
+			LocationID: LocationID,
+			Key:        gcpkms.Key{Keyring: "customers", Name: "customer-1"},
+			ChainID:    80001,
+		},
+		Labels: map[string]string{"customer": "1"},
+	}
+
+	for _, protectionLevel := range []string{gcpkms.ProtectionLevelHSM, gcpkms.ProtectionLevelSoftware} {
+		cfg.ProtectionLevel = protectionLevel
+		cfg.Key.Name = "customer-" + strings.ToLower(protectionLevel)
+
+		c, address, err := gcpkms.CreateKeyWithOptions(ctx, cfg, s.ClientOptions())
+		if err != nil {
+			panic(err)
+		}
+		if address != c.GetAddress() {
+			panic("invalid address")
+		}
+
+		keyCfg := cfg.Config
+		keyCfg.Key.Version = "1"
+		versionClient, err := s.NewGoogleKMSClient(ctx, keyCfg)
+		if err != nil {
+			panic(err)
+		}
+		if versionClient.GetAddress() != address {
+			panic("invalid address for the key version")
+		}
+	}
+
+	// crypto keys are unique
+	if _, _, err = gcpkms.CreateKeyWithOptions(ctx, cfg, s.ClientOptions()); !errors.Is(err, common2.ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig with an existing crypto key, got %v", err))
+	}
+
+	// errors of the key creation are typed
+	cfg.Key.Name = "customer-3"
+	s.SetError(codes.PermissionDenied)
+	_, _, err = gcpkms.CreateKeyWithOptions(ctx, cfg, s.ClientOptions())
+	s.SetError(codes.OK)
+	if !errors.Is(err, common2.ErrPermissionDenied) {
+		panic(fmt.Sprintf("expected ErrPermissionDenied, got %v", err))
+	}
+
+	cfg.ProtectionLevel = "EXTERNAL"
+	cfg.Key.Name = "customer-2"
+	if _, _, err = gcpkms.CreateKeyWithOptions(ctx, cfg, s.ClientOptions()); !errors.Is(err, common2.ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig, got %v", err))
+	}
+}
+
+func TestServer_ListKeys(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	c, address, err := gcpkms.CreateKeyWithOptions(ctx, gcpkms.CreateKeyConfig{
+		Config: gcpkms.Config{
+			ProjectID:  ProjectID,
+			LocationID: LocationID,
+			Key:        gcpkms.Key{Keyring: "customers", Name: "customer-1"},
+			ChainID:    80001,
+		},
+		ProtectionLevel: gcpkms.ProtectionLevelSoftware,
+		Labels:          map[string]string{"customer": "1"},
+	}, s.ClientOptions())
+	if err != nil {
+		panic(err)
+	}
+	disabledCfg := s.NewKey()
+	if err = s.SetKeyVersionState(disabledCfg, kmspb.CryptoKeyVersion_DISABLED); err != nil {
+		panic(err)
+	}
+	destroyedCfg := s.NewKey()
+	if err = s.SetKeyVersionState(destroyedCfg, kmspb.CryptoKeyVersion_DESTROYED); err != nil {
+		panic(err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	s.AddKey(gcpkms.Config{
+		ProjectID:  ProjectID,
+		LocationID: LocationID,
+		Key:        gcpkms.Key{Keyring: KeyRing, Name: "p256", Version: "1"},
+	}, p256Key)
+
+	keys, err := c.ListKeys(ctx)
+	if err != nil {
+		panic(err)
+	}
+	if len(keys) != 2 {
+		panic(fmt.Sprintf("expected 2 keys, got %v", len(keys)))
+	}
+	if keys[0].Key != (gcpkms.Key{Keyring: "customers", Name: "customer-1", Version: "1"}) || keys[0].Address != address {
+		panic(fmt.Sprintf("invalid key %v", keys[0]))
+	}
+	if keys[0].Labels["customer"] != "1" || keys[0].ProtectionLevel != gcpkms.ProtectionLevelSoftware {
+		panic(fmt.Sprintf("invalid key %v", keys[0]))
+	}
+	if keys[1].Key != disabledCfg.Key || keys[1].State != kmspb.CryptoKeyVersion_DISABLED.String() ||
+		keys[1].Address != (common.Address{}) {
+		panic(fmt.Sprintf("invalid key %v", keys[1]))
+	}
+
+	keyInfo, err := gcpkms.FindKeyByAddressWithOptions(ctx, gcpkms.Config{ProjectID: ProjectID, LocationID: LocationID},
+		s.ClientOptions(), address)
+	if err != nil {
+		panic(err)
+	}
+	if keyInfo.Name != "projects/test-project/locations/global/keyRings/customers/cryptoKeys/customer-1/cryptoKeyVersions/1" {
+		panic(fmt.Sprintf("invalid key found %v", keyInfo.Name))
+	}
+
+	_, err = c.FindKeyByAddress(ctx, common.HexToAddress("0x1"))
+	if !errors.Is(err, common2.ErrKeyNotFound) {
+		panic(fmt.Sprintf("expected ErrKeyNotFound, got %v", err))
+	}
+
+	_, err = gcpkms.ListKeysWithOptions(ctx, gcpkms.Config{ProjectID: ProjectID}, s.ClientOptions())
+	if !errors.Is(err, common2.ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig, got %v", err))
+	}
+}
+
+func TestServer_KeyVersionRotation(t *testing.T) {
+	s, err := NewServer()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	cfg := s.NewKey()
+	cfg.ChainID = 80001
+	kmsClient, err := kms.NewKeyManagementClient(ctx, s.ClientOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	defer kmsClient.Close()
+
+	oldClient, err := s.NewGoogleKMSClient(ctx, cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	// rotate the key
+	_, err = kmsClient.CreateCryptoKeyVersion(ctx, &kmspb.CreateCryptoKeyVersionRequest{
+		Parent: fmt.Sprintf("projects/%s/locations/%s/keyRings/%s/cryptoKeys/%s",
+			cfg.ProjectID, cfg.LocationID, cfg.Key.Keyring, cfg.Key.Name),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	for _, version := range []string{gcpkms.VersionLatestEnabled, gcpkms.VersionPrimary} {
+		latestCfg := cfg
+		latestCfg.Key.Version = version
+		c, err := s.NewGoogleKMSClient(ctx, latestCfg)
+		if err != nil {
+			panic(err)
+		}
+		if c.GetKey().Version != "2" {
+			panic(fmt.Sprintf("expected version 2 for %v, got %v", version, c.GetKey().Version))
+		}
+		if c.GetAddress() == oldClient.GetAddress() {
+			panic("expected a different address for the new version")
+		}
+	}
+
+	newClient, err := oldClient.WithKeyVersion(gcpkms.VersionLatestEnabled)
+	if err != nil {
+		panic(err)
+	}
+	if newClient.GetKey().Version != "2" {
+		panic(fmt.Sprintf("expected version 2, got %v", newClient.GetKey().Version))
+	}
+
+	versions, err := newClient.ListEnabledVersions(ctx)
+	if err != nil {
+		panic(err)
+	}
+	if len(versions) != 2 || versions[0].Address != oldClient.GetAddress() || versions[1].Address != newClient.GetAddress() {
+		panic(fmt.Sprintf("invalid enabled versions %v", versions))
+	}
+
+	// the old version is still usable to migrate the funds
+	v1Client, err := newClient.WithKeyVersion("1")
+	if err != nil {
+		panic(err)
+	}
+	if v1Client.GetAddress() != oldClient.GetAddress() {
+		panic("invalid address for version 1")
+	}
+
+	// disabling the new version makes the old one the latest enabled version
+	newCfg := cfg
+	newCfg.Key.Version = "2"
+	if err = s.SetKeyVersionState(newCfg, kmspb.CryptoKeyVersion_DISABLED); err != nil {
+		panic(err)
+	}
+	c, err := oldClient.WithKeyVersion(gcpkms.VersionLatestEnabled)
+	if err != nil {
+		panic(err)
+	}
+	if c.GetKey().Version != "1" {
+		panic(fmt.Sprintf("expected version 1, got %v", c.GetKey().Version))
+	}
+
+	if err = s.SetKeyVersionState(cfg, kmspb.CryptoKeyVersion_DISABLED); err != nil {
+		panic(err)
+	}
+	_, err = oldClient.WithKeyVersion(gcpkms.VersionLatestEnabled)
+	if !errors.Is(err, common2.ErrKeyNotFound) {
+		panic(fmt.Sprintf("expected ErrKeyNotFound, got %v", err))
+	}
+}
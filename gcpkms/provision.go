@@ -0,0 +1,171 @@
+package gcpkms
+
+import (
+	kms "cloud.google.com/go/kms/apiv1"
+	"context"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"google.golang.org/api/option"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+const (
+	// ProtectionLevelHSM protects the key in a Cloud HSM.
+	ProtectionLevelHSM = "HSM"
+
+	// ProtectionLevelSoftware protects the key in software.
+	ProtectionLevelSoftware = "SOFTWARE"
+
+	// initialKeyVersion is the version of the first CryptoKeyVersion, created along with the CryptoKey.
+	initialKeyVersion = "1"
+)
+
+// CreateKeyConfig consists of the information to create a new EVM signing key on the GCP KMS.
+//
+// The key ring (Config.Key.Keyring) is created if it does not exist, while the crypto key (Config.Key.Name)
+// must not exist. Config.Key.Version is ignored.
+type CreateKeyConfig struct {
+	Config
+
+	// ProtectionLevel is the protection level of the key: "HSM" (default) or "SOFTWARE".
+	ProtectionLevel string `json:"ProtectionLevel,omitempty"`
+
+	// Labels are the labels of the crypto key.
+	Labels map[string]string `json:"Labels,omitempty"`
+}
+
+// IsValid checks if a CreateKeyConfig is valid.
+func (cfg CreateKeyConfig) IsValid() (bool, error) {
+	keyCfg := cfg.Config
+	keyCfg.Key.Version = initialKeyVersion
+	if _, err := keyCfg.IsValid(); err != nil {
+		return false, err
+	}
+
+	if _, err := cfg.protectionLevel(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// protectionLevel returns the kmspb.ProtectionLevel of the config.
+func (cfg CreateKeyConfig) protectionLevel() (kmspb.ProtectionLevel, error) {
+	switch strings.ToUpper(cfg.ProtectionLevel) {
+	case "", ProtectionLevelHSM:
+		return kmspb.ProtectionLevel_HSM, nil
+	case ProtectionLevelSoftware:
+		return kmspb.ProtectionLevel_SOFTWARE, nil
+	default:
+		return kmspb.ProtectionLevel_PROTECTION_LEVEL_UNSPECIFIED, fmt.Errorf("ProtectionLevel `%v` not supported", cfg.ProtectionLevel)
+	}
+}
+
+// CreateKey creates a new EC_SIGN_SECP256K1_SHA256 crypto key on the GCP KMS, waits for its first version to be
+// enabled, and returns a GoogleKMSClient for this version together with its EVM address.
+//
+// If txSigner is not provided, the signer will be initiated as a types.NewLondonSigner(cfg.ChainID).
+// Note that only the first value of txSigner is used.
+func CreateKey(ctx context.Context, cfg CreateKeyConfig, txSigner ...types.Signer) (*GoogleKMSClient, common.Address, error) {
+	options, err := cfg.clientOptions()
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+
+	return CreateKeyWithOptions(ctx, cfg, options, txSigner...)
+}
+
+// CreateKeyWithOptions is the same as CreateKey, but the underlying kms.KeyManagementClient is created with the given
+// client options. Note that the credentials of cfg, cfg.Endpoint and cfg.Insecure are ignored.
+func CreateKeyWithOptions(ctx context.Context, cfg CreateKeyConfig, options []option.ClientOption, txSigner ...types.Signer) (*GoogleKMSClient, common.Address, error) {
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+	protectionLevel, _ := cfg.protectionLevel()
+
+	client, err := kms.NewKeyManagementClient(ctx, options...)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+
+	keyCfg := cfg.Config
+	keyCfg.Key.Version = initialKeyVersion
+	if err = createKey(ctx, client, keyCfg, protectionLevel, cfg.Labels); err != nil {
+		_ = client.Close()
+		return nil, common.Address{}, err
+	}
+
+	c, err := newGoogleKMSClient(ctx, keyCfg, client, txSigner...)
+	if err != nil {
+		_ = client.Close()
+		return nil, common.Address{}, err
+	}
+
+	return c, c.GetAddress(), nil
+}
+
+// createKey creates the key ring (if needed) and the crypto key given by cfg, and waits for the key version given by
+// cfg to be enabled.
+func createKey(ctx context.Context, client *kms.KeyManagementClient, cfg Config,
+	protectionLevel kmspb.ProtectionLevel, labels map[string]string,
+) error {
+	locationName := fmt.Sprintf("projects/%s/locations/%s", cfg.ProjectID, cfg.LocationID)
+	keyRingName := fmt.Sprintf("%s/keyRings/%s", locationName, cfg.Key.Keyring)
+
+	_, err := client.GetKeyRing(ctx, &kmspb.GetKeyRingRequest{Name: keyRingName})
+	if status.Code(err) == codes.NotFound {
+		_, err = client.CreateKeyRing(ctx, &kmspb.CreateKeyRingRequest{
+			Parent:    locationName,
+			KeyRingId: cfg.Key.Keyring,
+			KeyRing:   &kmspb.KeyRing{},
+		})
+		if status.Code(err) == codes.AlreadyExists {
+			err = nil
+		}
+	}
+	if err != nil {
+		return wrapError("CreateKeyRing", fmt.Errorf("cannot create key ring %v: %w", keyRingName, err))
+	}
+
+	_, err = client.CreateCryptoKey(ctx, &kmspb.CreateCryptoKeyRequest{
+		Parent:      keyRingName,
+		CryptoKeyId: cfg.Key.Name,
+		CryptoKey: &kmspb.CryptoKey{
+			Purpose: kmspb.CryptoKey_ASYMMETRIC_SIGN,
+			VersionTemplate: &kmspb.CryptoKeyVersionTemplate{
+				Algorithm:       kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256,
+				ProtectionLevel: protectionLevel,
+			},
+			Labels: labels,
+		},
+	})
+	if err != nil {
+		return wrapError("CreateCryptoKey", fmt.Errorf("cannot create crypto key %v: %w", cfg.Key.Name, err))
+	}
+
+	err = common2.PollUntil(ctx, func(ctx context.Context) (bool, error) {
+		version, err := client.GetCryptoKeyVersion(ctx, &kmspb.GetCryptoKeyVersionRequest{Name: cfg.keyVersionName()})
+		if err != nil {
+			return false, wrapError("GetCryptoKeyVersion", err)
+		}
+
+		switch version.State {
+		case kmspb.CryptoKeyVersion_ENABLED:
+			return true, nil
+		case kmspb.CryptoKeyVersion_PENDING_GENERATION:
+			return false, nil
+		default:
+			return false, fmt.Errorf("%w: key version %v is in state %v", common2.ErrKeyDisabled, version.Name, version.State)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("key version %v is not enabled: %w", cfg.keyVersionName(), err)
+	}
+
+	return nil
+}
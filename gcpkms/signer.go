@@ -6,14 +6,14 @@ import (
 	"crypto/ecdsa"
 	"encoding/asn1"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	common2 "github.com/LampardNguyen234/evm-kms/common"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/crypto/secp256k1"
-	"google.golang.org/api/iterator"
+	"github.com/ethereum/go-ethereum/signer/core"
 	"google.golang.org/api/option"
 	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
 	"google.golang.org/protobuf/types/known/wrapperspb"
@@ -35,25 +35,59 @@ type GoogleKMSClient struct {
 // If txSigner is not provided, the signer will be initiated as a types.NewLondonSigner(cfg.ChainID).
 // Note that only the first value of txSigner is used.
 func NewGoogleKMSClient(ctx context.Context, cfg Config, txSigner ...types.Signer) (*GoogleKMSClient, error) {
-	if _, err := cfg.IsValid(); err != nil {
-		return nil, fmt.Errorf("invalid config")
+	options, err := cfg.clientOptions()
+	if err != nil {
+		return nil, err
 	}
-	options := make([]option.ClientOption, 0)
-	if cfg.CredentialLocation != "" {
-		options = append(options, option.WithCredentialsFile(cfg.CredentialLocation))
+
+	return NewGoogleKMSClientWithOptions(ctx, cfg, options, txSigner...)
+}
+
+// NewGoogleKMSClientWithOptions creates a new GCP KMS client with the given config, whose underlying
+// kms.KeyManagementClient is created with the given client options (e.g, a custom endpoint or gRPC connection).
+// Note that the credentials of cfg, cfg.Endpoint and cfg.Insecure are ignored; use the corresponding options instead.
+//
+// If txSigner is not provided, the signer will be initiated as a types.NewLondonSigner(cfg.ChainID).
+// Note that only the first value of txSigner is used.
+func NewGoogleKMSClientWithOptions(ctx context.Context, cfg Config, options []option.ClientOption, txSigner ...types.Signer) (*GoogleKMSClient, error) {
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
 	}
 	client, err := kms.NewKeyManagementClient(ctx, options...)
 	if err != nil {
 		return nil, err
 	}
 
-	signer := types.NewLondonSigner(new(big.Int).SetUint64(cfg.ChainID))
-	if len(txSigner) > 0 {
-		signer = txSigner[0]
+	c, err := newGoogleKMSClient(ctx, cfg, client, txSigner...)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// newGoogleKMSClient creates a new GCP KMS client with the given config and kms.KeyManagementClient. The version of
+// the key is resolved if it is VersionLatestEnabled or VersionPrimary.
+func newGoogleKMSClient(ctx context.Context, cfg Config, client *kms.KeyManagementClient, txSigner ...types.Signer) (*GoogleKMSClient, error) {
+	signer := common2.NewTxSigner(cfg.ChainID, txSigner...)
+
+	if cfg.Key.Version == VersionLatestEnabled || cfg.Key.Version == VersionPrimary {
+		version, err := resolveKeyVersion(ctx, client, cfg)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Key.Version = version
 	}
 
 	c := &GoogleKMSClient{kmsClient: client, ctx: ctx, cfg: cfg, signer: signer}
 
+	if !cfg.SkipKeyValidation {
+		if err := c.validateKey(); err != nil {
+			return nil, err
+		}
+	}
+
 	pubKey, err := c.getPublicKey()
 	if err != nil {
 		return nil, err
@@ -77,6 +111,11 @@ func (c GoogleKMSClient) GetPublicKey() (*ecdsa.PublicKey, error) {
 // Although the GCP KMS does not support keccak256 hash function (it uses SHA256 instead), it will not care about
 // which hash function to use if you send the hash of message to the KMS.
 func (c GoogleKMSClient) SignHash(digest common.Hash) ([]byte, error) {
+	return c.SignHashWithContext(c.ctx, digest)
+}
+
+// SignHashWithContext is the same as SignHash, but the remote call is bound to the given context.
+func (c GoogleKMSClient) SignHashWithContext(ctx context.Context, digest common.Hash) ([]byte, error) {
 	// calculate the digest of the message
 
 	// compute digest's CRC32C
@@ -89,8 +128,7 @@ func (c GoogleKMSClient) SignHash(digest common.Hash) ([]byte, error) {
 
 	// build the signing request
 	req := &kmspb.AsymmetricSignRequest{
-		Name: fmt.Sprintf("projects/%s/locations/%s/keyRings/%s/cryptoKeys/%s/cryptoKeyVersions/%s",
-			c.cfg.ProjectID, c.cfg.LocationID, c.cfg.Key.Keyring, c.cfg.Key.Name, c.cfg.Key.Version),
+		Name: c.cfg.keyVersionName(),
 		Digest: &kmspb.Digest{
 			// we send the hash to the remote KMS, not the actual data
 			Digest: &kmspb.Digest_Sha256{
@@ -101,69 +139,69 @@ func (c GoogleKMSClient) SignHash(digest common.Hash) ([]byte, error) {
 	}
 
 	// call the API
-	result, err := c.kmsClient.AsymmetricSign(c.ctx, req)
+	result, err := c.kmsClient.AsymmetricSign(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign digest: %v", err)
+		return nil, wrapError("AsymmetricSign", err)
 	}
 
 	// perform integrity verification on result
 	if result.VerifiedDigestCrc32C == false {
-		return nil, fmt.Errorf("AsymmetricSign: request corrupted in-transit")
+		return nil, common2.NewKMSError("AsymmetricSign", common2.ErrSignatureInvalid, fmt.Errorf("request corrupted in-transit"))
 	}
 	if int64(crc32c(result.Signature)) != result.SignatureCrc32C.Value {
-		return nil, fmt.Errorf("AsymmetricSign: response corrupted in-transit")
+		return nil, common2.NewKMSError("AsymmetricSign", common2.ErrSignatureInvalid, fmt.Errorf("response corrupted in-transit"))
 	}
 
 	return c.parseKMSSignature(digest, result.Signature)
 }
 
+// SignTypedData calls the remote GCP KMS to sign the EIP-712 digest of the given typed data.
+// If legacyV is set to true, the returned v will be either 27 or 28 (as returned by `eth_signTypedData_v4`).
+func (c GoogleKMSClient) SignTypedData(typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return c.SignTypedDataWithContext(c.ctx, typedData, legacyV)
+}
+
+// SignTypedDataWithContext is the same as SignTypedData, but the remote call is bound to the given context.
+func (c GoogleKMSClient) SignTypedDataWithContext(ctx context.Context, typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return common2.SignTypedData(ctx, c.SignHashWithContext, typedData, legacyV)
+}
+
+// SignPersonalMessage calls the remote GCP KMS to sign the given message prefixed with
+// "\x19Ethereum Signed Message:\n" and its length (EIP-191). The returned v is either 27 or 28 (as returned by `personal_sign`).
+func (c GoogleKMSClient) SignPersonalMessage(msg []byte) ([]byte, error) {
+	return c.SignPersonalMessageWithContext(c.ctx, msg)
+}
+
+// SignPersonalMessageWithContext is the same as SignPersonalMessage, but the remote call is bound to the given context.
+func (c GoogleKMSClient) SignPersonalMessageWithContext(ctx context.Context, msg []byte) ([]byte, error) {
+	return common2.SignPersonalMessage(ctx, c.SignHashWithContext, msg)
+}
+
 // GetDefaultEVMTransactor returns the default KMS-backed instance of bind.TransactOpts.
 // Only `Context`, `From`, and `Signer` fields are set.
 func (c GoogleKMSClient) GetDefaultEVMTransactor() *bind.TransactOpts {
-	return &bind.TransactOpts{
-		Context: c.ctx,
-		From:    c.GetAddress(),
-		Signer:  c.GetEVMSignerFn(),
-	}
+	return c.GetDefaultEVMTransactorWithContext(c.ctx)
+}
+
+// GetDefaultEVMTransactorWithContext is the same as GetDefaultEVMTransactor, but the given context is used for both
+// the transactor and the KMS calls made by its signer.
+func (c GoogleKMSClient) GetDefaultEVMTransactorWithContext(ctx context.Context) *bind.TransactOpts {
+	return common2.NewTransactor(ctx, c.GetAddress(), c.GetEVMSignerFnWithContext(ctx))
 }
 
 // GetEVMSignerFn returns the EVM signer using the GCP KMS.
 func (c GoogleKMSClient) GetEVMSignerFn() bind.SignerFn {
-	return func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
-		if addr != c.GetAddress() {
-			return nil, bind.ErrNotAuthorized
-		}
-
-		sig, err := c.SignHash(c.signer.Hash(tx))
-		if err != nil {
-			return nil, fmt.Errorf("cannot sign transaction: %v", err)
-		}
-
-		ret, err := tx.WithSignature(c.signer, sig)
-		if err != nil {
-			return nil, err
-		}
-
-		if _, err = c.HasSignedTx(ret); err != nil {
-			return nil, err
-		}
+	return c.GetEVMSignerFnWithContext(c.ctx)
+}
 
-		return ret, nil
-	}
+// GetEVMSignerFnWithContext returns the EVM signer using the GCP KMS, whose remote calls are bound to the given context.
+func (c GoogleKMSClient) GetEVMSignerFnWithContext(ctx context.Context) bind.SignerFn {
+	return common2.NewSignerFn(ctx, c.GetAddress(), c.signer, c.SignHashWithContext)
 }
 
 // HasSignedTx checks if the given tx is signed by the current GoogleKMSClient.
 func (c GoogleKMSClient) HasSignedTx(tx *types.Transaction) (bool, error) {
-	from, err := types.Sender(c.signer, tx)
-	if err != nil {
-		return false, fmt.Errorf("cannot get sender of the tx: %v", err)
-	}
-
-	if from != c.GetAddress() {
-		return false, fmt.Errorf("expected signer: %v, got %v", c.GetAddress(), from)
-	}
-
-	return true, nil
+	return common2.HasSignedTx(c.signer, c.GetAddress(), tx)
 }
 
 // WithSigner assigns the given signer to the GoogleKMSClient.
@@ -173,25 +211,33 @@ func (c *GoogleKMSClient) WithSigner(signer types.Signer) {
 
 // WithChainID assigns given chainID (and updates the corresponding signer) to the GoogleKMSClient.
 func (c *GoogleKMSClient) WithChainID(chainID *big.Int) {
-	if c.cfg.ChainID != chainID.Uint64() {
-		c.cfg.ChainID = chainID.Uint64()
-		c.signer = types.NewLondonSigner(chainID)
-	}
+	c.cfg.ChainID, c.signer = common2.SwitchChainID(c.cfg.ChainID, c.signer, chainID)
 }
 
 func (c GoogleKMSClient) getPublicKey() (*ecdsa.PublicKey, error) {
 	req := &kmspb.GetPublicKeyRequest{
-		Name: fmt.Sprintf("projects/%s/locations/%s/keyRings/%s/cryptoKeys/%s/cryptoKeyVersions/%s",
-			c.cfg.ProjectID, c.cfg.LocationID, c.cfg.Key.Keyring, c.cfg.Key.Name, c.cfg.Key.Version),
+		Name: c.cfg.keyVersionName(),
 	}
 	pubKey, err := c.kmsClient.GetPublicKey(c.ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("GetPublicKey", err)
 	}
 
 	return parseKMSPublicKey(pubKey)
 }
 
+// validateKey checks that the key version is an enabled secp256k1 signing key.
+func (c GoogleKMSClient) validateKey() error {
+	version, err := c.kmsClient.GetCryptoKeyVersion(c.ctx, &kmspb.GetCryptoKeyVersionRequest{
+		Name: c.cfg.keyVersionName(),
+	})
+	if err != nil {
+		return wrapError("GetCryptoKeyVersion", err)
+	}
+
+	return validateKeyVersion(version)
+}
+
 // parseKMSSignature parses a signature returned from the GCP KMS to a valid EVM-compatible signature.
 // A valid EVM signature is a 65-byte long RLP-encoded of the form R || S || V (https://eips.ethereum.org/EIPS/eip-155).
 func (c GoogleKMSClient) parseKMSSignature(digestedMsg common.Hash,
@@ -201,33 +247,22 @@ func (c GoogleKMSClient) parseKMSSignature(digestedMsg common.Hash,
 	var sig common2.KmsSignature
 	_, err := asn1.Unmarshal(kmsSignature, &sig)
 	if err != nil {
-		return nil, fmt.Errorf("cannot unmarshal kms signature: %v", err)
+		return nil, fmt.Errorf("%w: cannot unmarshal kms signature: %v", common2.ErrSignatureInvalid, err)
 	}
 
 	// convert the signature into a valid EVM signature.
 	return common2.KmsToEVMSignature(*c.publicKey, sig, digestedMsg)
 }
 
-func (c GoogleKMSClient) describe() error {
-	// Create the request to list KeyRings.
-	listKeyRingsReq := &kmspb.ListKeyRingsRequest{
-		Parent: fmt.Sprintf("projects/%s/locations/%s", c.cfg.ProjectID, c.cfg.LocationID),
+// validateKeyVersion checks that the given key version is an enabled secp256k1 signing key.
+func validateKeyVersion(version *kmspb.CryptoKeyVersion) error {
+	if version.Algorithm != kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256 {
+		return fmt.Errorf("%w: key version %v has algorithm %v, expected %v", common2.ErrWrongKeySpec,
+			version.Name, version.Algorithm, kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256)
 	}
 
-	// List the KeyRings.
-	it := c.kmsClient.ListKeyRings(c.ctx, listKeyRingsReq)
-
-	// Iterate and print the results.
-	for {
-		resp, err := it.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("failed to list Key rings: %v", err)
-		}
-
-		fmt.Printf("Key ring: %s\n", resp.Name)
+	if version.State != kmspb.CryptoKeyVersion_ENABLED {
+		return fmt.Errorf("%w: key version %v is in state %v", common2.ErrKeyDisabled, version.Name, version.State)
 	}
 
 	return nil
@@ -235,25 +270,21 @@ func (c GoogleKMSClient) describe() error {
 
 // parseKMSPublicKey parses a public Key returned from the GCP KMS to a valid ecdsa.PublicKey.
 func parseKMSPublicKey(kmsPubKey *kmspb.PublicKey) (*ecdsa.PublicKey, error) {
-	block, _ := pem.Decode([]byte(kmsPubKey.Pem))
-	if block == nil || block.Type != "PUBLIC KEY" || len(block.Bytes) < 64 {
-		return nil, fmt.Errorf("cannot decode public Key %v", kmsPubKey.Pem)
+	if kmsPubKey.Algorithm != kmspb.CryptoKeyVersion_CRYPTO_KEY_VERSION_ALGORITHM_UNSPECIFIED &&
+		kmsPubKey.Algorithm != kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256 {
+		return nil, fmt.Errorf("%w: expected algorithm %v, got %v", common2.ErrWrongKeySpec,
+			kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256, kmsPubKey.Algorithm)
 	}
 
-	// last 64 bytes of block.Bytes are: x, y
-	pubKeyBytes := block.Bytes[len(block.Bytes)-64:]
-	x := new(big.Int).SetBytes(pubKeyBytes[:32])
-	y := new(big.Int).SetBytes(pubKeyBytes[32:])
-
-	// check if the point is on the secp256k1 curve
-	if !secp256k1.S256().IsOnCurve(x, y) {
-		return nil, fmt.Errorf("invalid secp256k1 public Key %v", kmsPubKey.Pem)
+	block, _ := pem.Decode([]byte(kmsPubKey.Pem))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("%w: cannot decode public Key %v", common2.ErrWrongKeySpec, kmsPubKey.Pem)
 	}
-	pubKey := ecdsa.PublicKey{
-		Curve: secp256k1.S256(),
-		X:     x,
-		Y:     y,
+
+	pubKey, err := common2.ParsePKIXPublicKey(block.Bytes)
+	if err != nil && !errors.Is(err, common2.ErrWrongKeySpec) {
+		return nil, fmt.Errorf("%w: %v", common2.ErrWrongKeySpec, err)
 	}
 
-	return &pubKey, nil
+	return pubKey, err
 }
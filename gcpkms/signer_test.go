@@ -5,22 +5,24 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
 	"github.com/LampardNguyen234/evm-kms/common/erc20"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	math2 "github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/signer/core"
 	"math"
 	"math/big"
+	"os"
+	"sync"
 	"testing"
 	"time"
 )
 
-var cfg *Config
-var c *GoogleKMSClient
-
 var (
 	receiverAddr = common.HexToAddress("0x243e9517a24813a2d73e9a74cd2c1c699d0ff7a5")
 	rpcHost      = "https://rpc-mumbai.maticvigil.com/"
@@ -28,24 +30,42 @@ var (
 	numTests     = 10
 )
 
-func init() {
-	var err error
-	cfg = &Config{
-		ProjectID:          "evm-kms",
-		LocationID:         "us-west1",
-		CredentialLocation: "/Users/SomeUser/.cred/gcp-credential.json",
-		Key: Key{
-			Keyring: "my-keying-name",
-			Name:    "evm-ecdsa",
-			Version: "1",
-		},
-		ChainID: 80001,
-	}
+// liveTestEnv is the environment variable holding the path of the credentials file of the live tests.
+const liveTestEnv = "GCPKMS_TEST_CREDENTIALS"
 
-	c, err = NewGoogleKMSClient(context.Background(), *cfg)
-	if err != nil {
-		panic(err)
+var (
+	cfg            *Config
+	c              *GoogleKMSClient
+	liveClientOnce sync.Once
+)
+
+// setupLiveClient initializes c with the credentials file given by liveTestEnv. The live tests require valid
+// credentials and network access, so they are skipped if liveTestEnv is not set.
+func setupLiveClient(t *testing.T) {
+	credentialLocation := os.Getenv(liveTestEnv)
+	if credentialLocation == "" {
+		t.Skipf("%v not set, skipping the live GCP KMS test", liveTestEnv)
 	}
+
+	liveClientOnce.Do(func() {
+		var err error
+		cfg = &Config{
+			ProjectID:          "evm-kms",
+			LocationID:         "us-west1",
+			CredentialLocation: credentialLocation,
+			Key: Key{
+				Keyring: "my-keying-name",
+				Name:    "evm-ecdsa",
+				Version: "1",
+			},
+			ChainID: 80001,
+		}
+
+		c, err = NewGoogleKMSClient(context.Background(), *cfg)
+		if err != nil {
+			panic(err)
+		}
+	})
 }
 
 func waitForReceipt(evmClient *ethclient.Client, txHash common.Hash) (*types.Receipt, error) {
@@ -67,14 +87,22 @@ func waitForReceipt(evmClient *ethclient.Client, txHash common.Hash) (*types.Rec
 	}
 }
 
-func TestDescribe(t *testing.T) {
-	err := c.describe()
+func TestGoogleKMSClient_ListKeys(t *testing.T) {
+	setupLiveClient(t)
+
+	keys, err := c.ListKeys(context.Background())
 	if err != nil {
 		panic(err)
 	}
+
+	for _, keyInfo := range keys {
+		fmt.Printf("key: %v, state: %v, address: %v\n", keyInfo.Name, keyInfo.State, keyInfo.Address)
+	}
 }
 
 func TestGoogleKMSClient_GetPublicKey(t *testing.T) {
+	setupLiveClient(t)
+
 	pubKey, err := c.GetPublicKey()
 	if err != nil {
 		panic(err)
@@ -84,12 +112,16 @@ func TestGoogleKMSClient_GetPublicKey(t *testing.T) {
 }
 
 func TestGoogleKMSClient_GetAddress(t *testing.T) {
+	setupLiveClient(t)
+
 	address := c.GetAddress()
 
 	fmt.Printf("address: %v\n", address)
 }
 
 func TestGoogleKMSClient_Sign(t *testing.T) {
+	setupLiveClient(t)
+
 	msg := []byte("Hello World")
 	_, err := c.SignHash(crypto.Keccak256Hash(msg))
 	if err != nil {
@@ -97,7 +129,86 @@ func TestGoogleKMSClient_Sign(t *testing.T) {
 	}
 }
 
+func TestGoogleKMSClient_SignHashWithContext(t *testing.T) {
+	setupLiveClient(t)
+
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := c.SignHashWithContext(ctx, digest); err != nil {
+		panic(err)
+	}
+
+	// a cancelled context must abort the remote call
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.SignHashWithContext(cancelledCtx, digest); err == nil {
+		panic("expected an error with a cancelled context")
+	}
+}
+
+func TestGoogleKMSClient_SignTypedData(t *testing.T) {
+	setupLiveClient(t)
+
+	typedData := core.TypedData{
+		Types: core.Types{
+			"EIP712Domain": []core.Type{
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"Greeting": []core.Type{
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Greeting",
+		Domain: core.TypedDataDomain{
+			Name:    "evm-kms",
+			ChainId: math2.NewHexOrDecimal256(1),
+		},
+		Message: core.TypedDataMessage{
+			"contents": "Hello World",
+		},
+	}
+
+	sig, err := c.SignTypedData(typedData, true)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("sig: %x\n", sig)
+
+	digest, err := common2.TypedDataHash(typedData)
+	if err != nil {
+		panic(err)
+	}
+	sig[64] -= 27
+	pubKey, err := crypto.SigToPub(digest[:], sig)
+	if err != nil {
+		panic(err)
+	}
+	if crypto.PubkeyToAddress(*pubKey) != c.GetAddress() {
+		panic("invalid typed data signature")
+	}
+}
+
+func TestGoogleKMSClient_SignPersonalMessage(t *testing.T) {
+	setupLiveClient(t)
+
+	msg := []byte("Hello World")
+	sig, err := c.SignPersonalMessage(msg)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("sig: %x\n", sig)
+
+	if _, err = common2.VerifyPersonalMessage(c.GetAddress(), msg, sig); err != nil {
+		panic(err)
+	}
+}
+
 func TestSendETH(t *testing.T) {
+	setupLiveClient(t)
+
 	ctx := context.Background()
 	evmClient, err := ethclient.Dial(rpcHost)
 	if err != nil {
@@ -159,6 +270,8 @@ func TestSendETH(t *testing.T) {
 }
 
 func TestSendERC20(t *testing.T) {
+	setupLiveClient(t)
+
 	evmClient, err := ethclient.Dial(rpcHost)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,112 @@
+package gcpkms
+
+import (
+	kms "cloud.google.com/go/kms/apiv1"
+	"context"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"google.golang.org/api/iterator"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+	"strconv"
+)
+
+const (
+	// VersionLatestEnabled resolves to the enabled EC_SIGN_SECP256K1_SHA256 version of the crypto key with the highest
+	// version ID.
+	VersionLatestEnabled = "latest-enabled"
+
+	// VersionPrimary resolves to the primary version of the crypto key. As Cloud KMS does not maintain a primary
+	// version for asymmetric keys, it falls back to VersionLatestEnabled if the crypto key has no primary version.
+	VersionPrimary = "primary"
+)
+
+// GetKey returns the detail of the key version used by the GoogleKMSClient, whose Version is always a version ID
+// (i.e, VersionLatestEnabled and VersionPrimary are resolved).
+func (c GoogleKMSClient) GetKey() Key {
+	return c.cfg.Key
+}
+
+// ListEnabledVersions lists all the enabled EC_SIGN_SECP256K1_SHA256 versions of the crypto key of the
+// GoogleKMSClient, together with their EVM addresses. This is useful to find the versions still holding funds after
+// a key rotation.
+func (c GoogleKMSClient) ListEnabledVersions(ctx context.Context) ([]KeyInfo, error) {
+	cryptoKey, err := c.kmsClient.GetCryptoKey(ctx, &kmspb.GetCryptoKeyRequest{Name: c.cfg.cryptoKeyName()})
+	if err != nil {
+		return nil, wrapError("GetCryptoKey", err)
+	}
+
+	keys, err := listKeyVersions(ctx, c.kmsClient, cryptoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]KeyInfo, 0)
+	for _, keyInfo := range keys {
+		if keyInfo.State == kmspb.CryptoKeyVersion_ENABLED.String() {
+			res = append(res, keyInfo)
+		}
+	}
+
+	return res, nil
+}
+
+// WithKeyVersion returns a new GoogleKMSClient for the given version of the crypto key of the current
+// GoogleKMSClient, sharing the same underlying kms.KeyManagementClient, context and signer. The version can be a
+// version ID, VersionLatestEnabled or VersionPrimary.
+//
+// For example, calling WithKeyVersion(VersionLatestEnabled) after a key rotation returns a client for the new version.
+func (c GoogleKMSClient) WithKeyVersion(version string) (*GoogleKMSClient, error) {
+	cfg := c.cfg
+	cfg.Key.Version = version
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+
+	return newGoogleKMSClient(c.ctx, cfg, c.kmsClient, c.signer)
+}
+
+// resolveKeyVersion resolves the version of cfg.Key (VersionLatestEnabled or VersionPrimary) to a version ID.
+func resolveKeyVersion(ctx context.Context, client *kms.KeyManagementClient, cfg Config) (string, error) {
+	if cfg.Key.Version == VersionPrimary {
+		cryptoKey, err := client.GetCryptoKey(ctx, &kmspb.GetCryptoKeyRequest{Name: cfg.cryptoKeyName()})
+		if err != nil {
+			return "", wrapError("GetCryptoKey", err)
+		}
+		if cryptoKey.Primary != nil {
+			return parseKeyVersionName(cryptoKey.Primary.Name).Version, nil
+		}
+	}
+
+	latestVersion := ""
+	latestVersionID := 0
+	it := client.ListCryptoKeyVersions(ctx, &kmspb.ListCryptoKeyVersionsRequest{Parent: cfg.cryptoKeyName()})
+	for {
+		version, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return "", wrapError("ListCryptoKeyVersions", err)
+		}
+		if version.State != kmspb.CryptoKeyVersion_ENABLED ||
+			version.Algorithm != kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256 {
+			continue
+		}
+
+		versionID := parseKeyVersionName(version.Name).Version
+		id, err := strconv.Atoi(versionID)
+		if err != nil {
+			return "", fmt.Errorf("invalid version ID of key version %v: %v", version.Name, err)
+		}
+		if id > latestVersionID {
+			latestVersion, latestVersionID = versionID, id
+		}
+	}
+
+	if latestVersion == "" {
+		return "", fmt.Errorf("%w: no enabled %v version of crypto key %v", common2.ErrKeyNotFound,
+			kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256, cfg.cryptoKeyName())
+	}
+
+	return latestVersion, nil
+}
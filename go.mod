@@ -4,13 +4,19 @@ go 1.16
 
 require (
 	cloud.google.com/go/kms v1.4.0
+	github.com/BurntSushi/toml v1.2.0
 	github.com/aws/aws-sdk-go-v2 v1.16.16
 	github.com/aws/aws-sdk-go-v2/config v1.17.8
 	github.com/aws/aws-sdk-go-v2/credentials v1.12.21
 	github.com/aws/aws-sdk-go-v2/service/kms v1.18.11
+	github.com/aws/aws-sdk-go-v2/service/sts v1.16.19
+	github.com/aws/smithy-go v1.13.3
 	github.com/ethereum/go-ethereum v1.10.5
+	github.com/miekg/pkcs11 v1.1.1
 	github.com/pkg/errors v0.9.1
 	google.golang.org/api v0.98.0
 	google.golang.org/genproto v0.0.0-20220930163606-c98284e70a91
+	google.golang.org/grpc v1.49.0
 	google.golang.org/protobuf v1.28.1
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,93 @@
+package keystorekms
+
+import (
+	"encoding/json"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// PassphraseFn returns the passphrase used to unlock a keystore file.
+type PassphraseFn func() (string, error)
+
+// Config represents required information to create a keystore KMS client.
+//
+// At most one of PassphraseEnv and PassphraseFile can be set. If none of them is set, the passphrase must be
+// provided via a PassphraseFn (see NewKeystoreKMSClientWithPassphraseFn).
+type Config struct {
+	// KeystoreFile is the path of the V3 keystore JSON file.
+	//
+	// Example: "/home/SomeUser/.ethereum/keystore/UTC--2022-10-01T00-00-00.000000000Z--2c7536e3605d9c16a7a3d7b1898e529396a65c23".
+	KeystoreFile string `json:"KeystoreFile"`
+
+	// PassphraseEnv is the name of the environment variable holding the passphrase of the keystore.
+	PassphraseEnv string `json:"PassphraseEnv,omitempty"`
+
+	// PassphraseFile is the path of the file holding the passphrase of the keystore.
+	// Trailing newlines of the file are ignored.
+	PassphraseFile string `json:"PassphraseFile,omitempty"`
+
+	// ChainID is the ID of the target EVM chain.
+	//
+	// See https://chainlist.org.
+	ChainID uint64 `json:"ChainID"`
+}
+
+// IsValid checks if a Config is valid.
+func (cfg Config) IsValid() (bool, error) {
+	if cfg.KeystoreFile == "" {
+		return false, fmt.Errorf("empty KeystoreFile")
+	}
+
+	if cfg.PassphraseEnv != "" && cfg.PassphraseFile != "" {
+		return false, fmt.Errorf("only one of PassphraseEnv and PassphraseFile must be set")
+	}
+
+	return true, nil
+}
+
+// LoadConfigFromFile loads the config from the given config file.
+func LoadConfigFromFile(filePath string) (*Config, error) {
+	f, err := common2.ReadConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	err = json.Unmarshal(f, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = cfg.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// passphraseFn returns the PassphraseFn corresponding to the passphrase source specified by the Config.
+func (cfg Config) passphraseFn() (PassphraseFn, error) {
+	switch {
+	case cfg.PassphraseEnv != "":
+		return func() (string, error) {
+			passphrase, ok := os.LookupEnv(cfg.PassphraseEnv)
+			if !ok {
+				return "", fmt.Errorf("environment variable %v is not set", cfg.PassphraseEnv)
+			}
+			return passphrase, nil
+		}, nil
+	case cfg.PassphraseFile != "":
+		return func() (string, error) {
+			f, err := ioutil.ReadFile(cfg.PassphraseFile)
+			if err != nil {
+				return "", fmt.Errorf("cannot read passphrase file: %v", err)
+			}
+			return strings.TrimRight(string(f), "\r\n"), nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("empty PassphraseEnv and PassphraseFile")
+}
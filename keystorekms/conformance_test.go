@@ -0,0 +1,25 @@
+package keystorekms_test
+
+import (
+	"context"
+	kms "github.com/LampardNguyen234/evm-kms"
+	"github.com/LampardNguyen234/evm-kms/keystorekms"
+	"github.com/LampardNguyen234/evm-kms/kmstest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	kmstest.RunConformance(t, func(t *testing.T) kms.KMSSigner {
+		keystoreFile, _ := keystorekms.NewTestKeystore(t.TempDir())
+
+		c, err := keystorekms.NewKeystoreKMSClientWithPassphraseFn(context.Background(),
+			keystorekms.Config{KeystoreFile: keystoreFile, ChainID: 1},
+			func() (string, error) { return keystorekms.TestPassphrase, nil },
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return c
+	})
+}
@@ -0,0 +1,7 @@
+// Package keystorekms provides a signing interface for EVM-compatible transactions backed by an encrypted Ethereum
+// keystore (V3 JSON) file.
+//
+// The keystore is unlocked once at construction with a passphrase sourced from a file, an environment variable or a
+// callback, and the decrypted private key is then held in memory. It is intended for environments (e.g, staging)
+// where a remote KMS is not available but the same KMSSigner interface is required.
+package keystorekms
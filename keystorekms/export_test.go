@@ -0,0 +1,6 @@
+package keystorekms
+
+// Exported for the external tests of the package.
+var NewTestKeystore = newTestKeystore
+
+const TestPassphrase = testPassphrase
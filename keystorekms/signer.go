@@ -0,0 +1,98 @@
+package keystorekms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/LampardNguyen234/evm-kms/localkms"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"io/ioutil"
+)
+
+// KeystoreKMSClient implements basic functionalities of a KMS client for signing transactions, using a private key
+// unlocked from an encrypted keystore file.
+//
+// Once unlocked, the signing operations are the same as a localkms.LocalKMSClient.
+type KeystoreKMSClient struct {
+	*localkms.LocalKMSClient
+}
+
+// NewKeystoreKMSClient creates a new keystore KMS client with the given config. The passphrase is read from
+// either cfg.PassphraseEnv or cfg.PassphraseFile.
+//
+// If txSigner is not provided, the signer will be initiated as a types.NewLondonSigner(cfg.ChainID).
+// Note that only the first value of txSigner is used.
+//
+// Example:
+//
+//	cfg := Config{
+//		KeystoreFile:   "/path/to/keystore/file",
+//		PassphraseFile: "/run/secrets/keystore-passphrase",
+//		ChainID:        1,
+//	}
+//
+//	c, err := NewKeystoreKMSClient(context.Background(), cfg)
+//	if err != nil {
+//		panic(err)
+//	}
+func NewKeystoreKMSClient(ctx context.Context, cfg Config, txSigner ...types.Signer) (*KeystoreKMSClient, error) {
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+
+	passphraseFn, err := cfg.passphraseFn()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+
+	return NewKeystoreKMSClientWithPassphraseFn(ctx, cfg, passphraseFn, txSigner...)
+}
+
+// NewKeystoreKMSClientWithPassphraseFn is an alternative of NewKeystoreKMSClient but retrieves the passphrase
+// via the given PassphraseFn (e.g, prompting the user, or querying a secret manager). The PassphraseEnv and
+// PassphraseFile fields of cfg are ignored.
+func NewKeystoreKMSClientWithPassphraseFn(ctx context.Context,
+	cfg Config,
+	passphraseFn PassphraseFn,
+	txSigner ...types.Signer,
+) (*KeystoreKMSClient, error) {
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+	if passphraseFn == nil {
+		return nil, fmt.Errorf("nil passphraseFn")
+	}
+
+	keyJSON, err := ioutil.ReadFile(cfg.KeystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read keystore file: %v", err)
+	}
+
+	passphrase, err := passphraseFn()
+	if err != nil {
+		return nil, fmt.Errorf("cannot retrieve passphrase: %v", err)
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unlock keystore: %v", err)
+	}
+
+	// double-check the address declared in the keystore file, if any.
+	var keyHeader struct {
+		Address string `json:"address"`
+	}
+	if err = json.Unmarshal(keyJSON, &keyHeader); err == nil && keyHeader.Address != "" {
+		if common.HexToAddress(keyHeader.Address) != key.Address {
+			return nil, fmt.Errorf("keystore address mismatch: expected %v, got %v",
+				common.HexToAddress(keyHeader.Address), key.Address)
+		}
+	}
+
+	return &KeystoreKMSClient{
+		LocalKMSClient: localkms.NewLocalKMSClientFromPrivateKey(ctx, key.PrivateKey, cfg.ChainID, txSigner...),
+	}, nil
+}
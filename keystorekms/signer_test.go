@@ -0,0 +1,99 @@
+package keystorekms
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testPassphrase = "evm-kms-passphrase"
+
+// newTestKeystore creates a keystore file in the given directory and returns its path and the corresponding address.
+func newTestKeystore(dir string) (string, string) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(privateKey, testPassphrase)
+	if err != nil {
+		panic(err)
+	}
+
+	return account.URL.Path, account.Address.Hex()
+}
+
+func TestNewKeystoreKMSClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore-kms")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keystoreFile, address := newTestKeystore(dir)
+
+	passphraseFile := filepath.Join(dir, "passphrase")
+	if err = ioutil.WriteFile(passphraseFile, []byte(testPassphrase+"\n"), 0600); err != nil {
+		panic(err)
+	}
+	os.Setenv("KEYSTORE_KMS_TEST_PASSPHRASE", testPassphrase)
+	defer os.Unsetenv("KEYSTORE_KMS_TEST_PASSPHRASE")
+
+	for _, cfg := range []Config{
+		{KeystoreFile: keystoreFile, PassphraseFile: passphraseFile, ChainID: 1},
+		{KeystoreFile: keystoreFile, PassphraseEnv: "KEYSTORE_KMS_TEST_PASSPHRASE", ChainID: 1},
+	} {
+		c, err := NewKeystoreKMSClient(context.Background(), cfg)
+		if err != nil {
+			panic(err)
+		}
+		if c.GetAddress().Hex() != address {
+			panic(fmt.Sprintf("expected address %v, got %v", address, c.GetAddress().Hex()))
+		}
+	}
+
+	c, err := NewKeystoreKMSClientWithPassphraseFn(context.Background(),
+		Config{KeystoreFile: keystoreFile, ChainID: 1},
+		func() (string, error) {
+			return testPassphrase, nil
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	// the unlocked client must be able to sign transactions
+	tx := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1000000), Gas: 50000})
+	c.WithChainID(big.NewInt(80001))
+	signedTx, err := c.GetEVMSignerFn()(c.GetAddress(), tx)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = c.HasSignedTx(signedTx); err != nil {
+		panic(err)
+	}
+
+	// wrong passphrase
+	_, err = NewKeystoreKMSClientWithPassphraseFn(context.Background(),
+		Config{KeystoreFile: keystoreFile, ChainID: 1},
+		func() (string, error) {
+			return "wrong-passphrase", nil
+		},
+	)
+	if err == nil {
+		panic("expected an error with a wrong passphrase")
+	}
+
+	// missing passphrase source
+	if _, err = NewKeystoreKMSClient(context.Background(), Config{KeystoreFile: keystoreFile}); err == nil {
+		panic("expected an error without a passphrase source")
+	}
+}
@@ -4,13 +4,11 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"fmt"
-	"github.com/LampardNguyen234/evm-kms/awskms"
-	"github.com/LampardNguyen234/evm-kms/gcpkms"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core"
 	"math/big"
-	"strings"
 )
 
 // KMSSigner specifies the required methods for a KMS signer
@@ -24,12 +22,36 @@ type KMSSigner interface {
 	// SignHash performs a signing operation for a given digested message.
 	SignHash(hash common.Hash) ([]byte, error)
 
+	// SignHashWithContext is the same as SignHash, but the remote call is bound to the given context.
+	SignHashWithContext(ctx context.Context, hash common.Hash) ([]byte, error)
+
+	// SignTypedData performs a signing operation for the given EIP-712 typed data.
+	// If legacyV is set to true, the returned v will be either 27 or 28 instead of 0 or 1.
+	SignTypedData(typedData core.TypedData, legacyV bool) ([]byte, error)
+
+	// SignTypedDataWithContext is the same as SignTypedData, but the remote call is bound to the given context.
+	SignTypedDataWithContext(ctx context.Context, typedData core.TypedData, legacyV bool) ([]byte, error)
+
+	// SignPersonalMessage performs an EIP-191 (`personal_sign`) signing operation for the given message.
+	// The returned v is either 27 or 28.
+	SignPersonalMessage(msg []byte) ([]byte, error)
+
+	// SignPersonalMessageWithContext is the same as SignPersonalMessage, but the remote call is bound to the given context.
+	SignPersonalMessageWithContext(ctx context.Context, msg []byte) ([]byte, error)
+
 	// GetDefaultEVMTransactor returns the default KMS-backed instance of bind.TransactOpts.
 	GetDefaultEVMTransactor() *bind.TransactOpts
 
+	// GetDefaultEVMTransactorWithContext is the same as GetDefaultEVMTransactor, but the given context is used for both
+	// the transactor and the KMS calls made by its signer.
+	GetDefaultEVMTransactorWithContext(ctx context.Context) *bind.TransactOpts
+
 	// GetEVMSignerFn returns the KMS-backed bind.SignerFn instance.
 	GetEVMSignerFn() bind.SignerFn
 
+	// GetEVMSignerFnWithContext returns the KMS-backed bind.SignerFn instance whose remote calls are bound to the given context.
+	GetEVMSignerFnWithContext(ctx context.Context) bind.SignerFn
+
 	// HasSignedTx checks if the given transaction has been signed by the KMS.
 	HasSignedTx(*types.Transaction) (bool, error)
 
@@ -43,23 +65,25 @@ type KMSSigner interface {
 // NewKMSSignerFromConfig creates and returns a new KMSSigner with the given config.
 func NewKMSSignerFromConfig(cfg Config) (KMSSigner, error) {
 	if _, err := cfg.IsValid(); err != nil {
-		return nil, fmt.Errorf("invalid config: %v", err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
 	}
 
-	ctx := context.Background()
-	switch strings.ToLower(cfg.Type) {
-	case awsType:
-		return awskms.NewAmazonKMSClientWithStaticCredentials(ctx, cfg.AwsConfig)
-	case gcpType:
-		return gcpkms.NewGoogleKMSClient(ctx, cfg.GcpConfig)
+	b, err := getBackend(cfg.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	rawConfig, err := cfg.backendConfig()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, nil
+	return b.factory(context.Background(), rawConfig)
 }
 
-// NewKMSSignerFromConfigFile creates and returns a new KMSSigner with the given config file.
+// NewKMSSignerFromConfigFile creates and returns a new KMSSigner with the given config file (JSON, YAML or TOML).
 func NewKMSSignerFromConfigFile(filePath string) (KMSSigner, error) {
-	cfg, err := LoadConfigFromJSONFile(filePath)
+	cfg, err := LoadConfigFromFile(filePath)
 	if err != nil {
 		return nil, err
 	}
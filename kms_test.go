@@ -0,0 +1,76 @@
+package kms
+
+import (
+	"errors"
+	"fmt"
+	"github.com/LampardNguyen234/evm-kms/localkms"
+	"os"
+	"testing"
+)
+
+func TestNewKMSSignerFromConfig_Local(t *testing.T) {
+	cfg, err := LoadConfig(map[string]interface{}{
+		"type": "local",
+		"local": map[string]interface{}{
+			"PrivateKey": "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",
+			"ChainID":    1,
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	kmsSigner, err := NewKMSSignerFromConfig(*cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	expected := "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	if kmsSigner.GetAddress().Hex() != expected {
+		panic(fmt.Sprintf("expected address %v, got %v", expected, kmsSigner.GetAddress().Hex()))
+	}
+}
+
+func TestNewKMSSignerFromConfig_Typed(t *testing.T) {
+	kmsSigner, err := NewKMSSignerFromConfig(Config{
+		Type: localType,
+		LocalConfig: localkms.Config{
+			PrivateKey: "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",
+			ChainID:    1,
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	expected := "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	if kmsSigner.GetAddress().Hex() != expected {
+		panic(fmt.Sprintf("expected address %v, got %v", expected, kmsSigner.GetAddress().Hex()))
+	}
+
+	// an empty typed config section
+	_, err = NewKMSSignerFromConfig(Config{Type: localType})
+	if !errors.Is(err, ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig, got %v", err))
+	}
+}
+
+func TestLoadConfig_VaultEnv(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"type":  vaultType,
+		"vault": map[string]interface{}{"KeyName": "evm-ecdsa", "ChainID": 1},
+	}
+	if _, err := LoadConfig(rawConfig); err == nil {
+		panic("expected an error without Address and Token")
+	}
+
+	for key, value := range map[string]string{"VAULT_ADDR": "https://vault.example.com:8200", "VAULT_TOKEN": "s.token"} {
+		if err := os.Setenv(key, value); err != nil {
+			panic(err)
+		}
+		defer os.Unsetenv(key)
+	}
+	if _, err := LoadConfig(rawConfig); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,322 @@
+package kmstest
+
+import (
+	"context"
+	"fmt"
+	kms "github.com/LampardNguyen234/evm-kms"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"math/big"
+	"testing"
+)
+
+const (
+	// numSignatures is the number of signatures checked by the signature tests. As a high S is returned about half
+	// of the time by remote KMSs, this makes it very unlikely for the low-S normalization to go untested.
+	numSignatures = 20
+)
+
+var (
+	chainID      = big.NewInt(80001)
+	otherChainID = big.NewInt(5)
+	receiverAddr = common.HexToAddress("0x243e9517a24813a2d73e9a74cd2c1c699d0ff7a5")
+)
+
+// Factory creates a new KMSSigner for a single test. Each call should return a fresh KMSSigner, as tests may change
+// its chain ID or signer. Setup failures should be reported via t.Fatal.
+type Factory func(t *testing.T) kms.KMSSigner
+
+// RunConformance runs the conformance test suite against the KMSSigners created by factory.
+func RunConformance(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, signer kms.KMSSigner)
+	}{
+		{"AddressPublicKey", testAddressPublicKey},
+		{"LowS", testLowS},
+		{"RecoverV", testRecoverV},
+		{"SignPersonalMessage", testSignPersonalMessage},
+		{"SignTypedData", testSignTypedData},
+		{"SignLegacyTx", testSignTx(newLegacyTx)},
+		{"SignAccessListTx", testSignTx(newAccessListTx)},
+		{"SignDynamicFeeTx", testSignTx(newDynamicFeeTx)},
+		{"DefaultEVMTransactor", testDefaultEVMTransactor},
+		{"HasSignedTxNegative", testHasSignedTxNegative},
+		{"WithChainID", testWithChainID},
+		{"NotAuthorized", testNotAuthorized},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			signer := factory(t)
+			signer.WithChainID(chainID)
+			tc.fn(t, signer)
+		})
+	}
+}
+
+func testAddressPublicKey(t *testing.T, signer kms.KMSSigner) {
+	pubKey, err := signer.GetPublicKey()
+	if err != nil {
+		t.Fatalf("GetPublicKey: %v", err)
+	}
+	if pubKey == nil || !crypto.S256().IsOnCurve(pubKey.X, pubKey.Y) {
+		t.Fatalf("invalid secp256k1 public key")
+	}
+
+	if addr := crypto.PubkeyToAddress(*pubKey); addr != signer.GetAddress() {
+		t.Fatalf("public key address %v does not match GetAddress %v", addr, signer.GetAddress())
+	}
+}
+
+func testLowS(t *testing.T, signer kms.KMSSigner) {
+	for i := 0; i < numSignatures; i++ {
+		digest := crypto.Keccak256Hash([]byte(fmt.Sprintf("low-s %v", i)))
+		sig := signHash(t, signer, digest)
+
+		if s := new(big.Int).SetBytes(sig[32:64]); s.Cmp(common2.CurveOrderHalf) > 0 {
+			t.Fatalf("signature of %v has a high S: %x", digest, sig)
+		}
+	}
+}
+
+func testRecoverV(t *testing.T, signer kms.KMSSigner) {
+	for i := 0; i < numSignatures; i++ {
+		digest := crypto.Keccak256Hash([]byte(fmt.Sprintf("recover-v %v", i)))
+		sig := signHash(t, signer, digest)
+
+		if sig[64] != 0 && sig[64] != 1 {
+			t.Fatalf("expected v to be 0 or 1, got %v", sig[64])
+		}
+
+		pubKey, err := crypto.SigToPub(digest[:], sig)
+		if err != nil {
+			t.Fatalf("cannot recover public key: %v", err)
+		}
+		if addr := crypto.PubkeyToAddress(*pubKey); addr != signer.GetAddress() {
+			t.Fatalf("recovered address %v does not match GetAddress %v", addr, signer.GetAddress())
+		}
+	}
+}
+
+func testSignPersonalMessage(t *testing.T, signer kms.KMSSigner) {
+	msg := []byte("Hello World")
+	sig, err := signer.SignPersonalMessage(msg)
+	if err != nil {
+		t.Fatalf("SignPersonalMessage: %v", err)
+	}
+	if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+		t.Fatalf("expected a 65-byte signature with v = 27 or 28, got %x", sig)
+	}
+
+	if ok, err := common2.VerifyPersonalMessage(signer.GetAddress(), msg, sig); !ok {
+		t.Fatalf("invalid personal message signature: %v", err)
+	}
+}
+
+func testSignTypedData(t *testing.T, signer kms.KMSSigner) {
+	typedData := core.TypedData{
+		Types: core.Types{
+			"EIP712Domain": []core.Type{
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"Greeting": []core.Type{
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Greeting",
+		Domain: core.TypedDataDomain{
+			Name:    "evm-kms",
+			ChainId: math.NewHexOrDecimal256(chainID.Int64()),
+		},
+		Message: core.TypedDataMessage{
+			"contents": "Hello World",
+		},
+	}
+	digest, err := common2.TypedDataHash(typedData)
+	if err != nil {
+		t.Fatalf("TypedDataHash: %v", err)
+	}
+
+	for _, legacyV := range []bool{false, true} {
+		sig, err := signer.SignTypedData(typedData, legacyV)
+		if err != nil {
+			t.Fatalf("SignTypedData(legacyV = %v): %v", legacyV, err)
+		}
+		if len(sig) != 65 {
+			t.Fatalf("expected a 65-byte signature, got %x", sig)
+		}
+
+		sig = common.CopyBytes(sig)
+		if legacyV {
+			if sig[64] != 27 && sig[64] != 28 {
+				t.Fatalf("expected v to be 27 or 28, got %v", sig[64])
+			}
+			sig[64] -= 27
+		} else if sig[64] != 0 && sig[64] != 1 {
+			t.Fatalf("expected v to be 0 or 1, got %v", sig[64])
+		}
+
+		pubKey, err := crypto.SigToPub(digest[:], sig)
+		if err != nil {
+			t.Fatalf("cannot recover public key: %v", err)
+		}
+		if addr := crypto.PubkeyToAddress(*pubKey); addr != signer.GetAddress() {
+			t.Fatalf("recovered address %v does not match GetAddress %v", addr, signer.GetAddress())
+		}
+	}
+}
+
+func testSignTx(newTx func(chainID *big.Int, nonce uint64) *types.Transaction) func(t *testing.T, signer kms.KMSSigner) {
+	return func(t *testing.T, signer kms.KMSSigner) {
+		signerFn := signer.GetEVMSignerFn()
+		for i := 0; i < numSignatures; i++ {
+			signedTx, err := signerFn(signer.GetAddress(), newTx(chainID, uint64(i)))
+			if err != nil {
+				t.Fatalf("cannot sign transaction: %v", err)
+			}
+			checkSender(t, signer, signedTx, chainID)
+		}
+	}
+}
+
+func testDefaultEVMTransactor(t *testing.T, signer kms.KMSSigner) {
+	ctx := context.Background()
+	transactor := signer.GetDefaultEVMTransactorWithContext(ctx)
+	if transactor.From != signer.GetAddress() {
+		t.Fatalf("expected transactor.From %v, got %v", signer.GetAddress(), transactor.From)
+	}
+	if transactor.Context != ctx {
+		t.Fatalf("transactor.Context is not the given context")
+	}
+
+	signedTx, err := transactor.Signer(transactor.From, newDynamicFeeTx(chainID, 0))
+	if err != nil {
+		t.Fatalf("cannot sign transaction: %v", err)
+	}
+	checkSender(t, signer, signedTx, chainID)
+}
+
+func testHasSignedTxNegative(t *testing.T, signer kms.KMSSigner) {
+	tx := newDynamicFeeTx(chainID, 0)
+	if signed, _ := signer.HasSignedTx(tx); signed {
+		t.Fatalf("HasSignedTx returned true for an unsigned transaction")
+	}
+
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	signedTx, err := types.SignTx(tx, types.NewLondonSigner(chainID), otherKey)
+	if err != nil {
+		t.Fatalf("cannot sign transaction: %v", err)
+	}
+	if signed, _ := signer.HasSignedTx(signedTx); signed {
+		t.Fatalf("HasSignedTx returned true for a transaction signed by another key")
+	}
+}
+
+func testWithChainID(t *testing.T, signer kms.KMSSigner) {
+	signedTx, err := signer.GetEVMSignerFn()(signer.GetAddress(), newLegacyTx(chainID, 0))
+	if err != nil {
+		t.Fatalf("cannot sign transaction: %v", err)
+	}
+
+	signer.WithChainID(otherChainID)
+	if signed, _ := signer.HasSignedTx(signedTx); signed {
+		t.Fatalf("HasSignedTx returned true for a transaction of the previous chain")
+	}
+
+	for _, newTx := range []func(*big.Int, uint64) *types.Transaction{newLegacyTx, newAccessListTx, newDynamicFeeTx} {
+		signedTx, err = signer.GetEVMSignerFn()(signer.GetAddress(), newTx(otherChainID, 1))
+		if err != nil {
+			t.Fatalf("cannot sign transaction after switching chain ID: %v", err)
+		}
+		checkSender(t, signer, signedTx, otherChainID)
+	}
+}
+
+func testNotAuthorized(t *testing.T, signer kms.KMSSigner) {
+	tx := newDynamicFeeTx(chainID, 0)
+	if _, err := signer.GetEVMSignerFn()(receiverAddr, tx); err != bind.ErrNotAuthorized {
+		t.Fatalf("expected bind.ErrNotAuthorized, got %v", err)
+	}
+
+	transactor := signer.GetDefaultEVMTransactor()
+	if _, err := transactor.Signer(receiverAddr, tx); err != bind.ErrNotAuthorized {
+		t.Fatalf("expected bind.ErrNotAuthorized from the default transactor, got %v", err)
+	}
+}
+
+// signHash signs the given digest and checks the length of the signature.
+func signHash(t *testing.T, signer kms.KMSSigner, digest common.Hash) []byte {
+	sig, err := signer.SignHash(digest)
+	if err != nil {
+		t.Fatalf("SignHash: %v", err)
+	}
+	if len(sig) != 65 {
+		t.Fatalf("expected a 65-byte signature, got %v bytes", len(sig))
+	}
+
+	return sig
+}
+
+// checkSender checks that the given transaction is signed by the signer for the given chain.
+func checkSender(t *testing.T, signer kms.KMSSigner, tx *types.Transaction, chainID *big.Int) {
+	from, err := types.Sender(types.NewLondonSigner(chainID), tx)
+	if err != nil {
+		t.Fatalf("cannot get sender of the tx: %v", err)
+	}
+	if from != signer.GetAddress() {
+		t.Fatalf("expected sender %v, got %v", signer.GetAddress(), from)
+	}
+
+	signed, err := signer.HasSignedTx(tx)
+	if err != nil || !signed {
+		t.Fatalf("HasSignedTx returned false for a signed transaction: %v", err)
+	}
+}
+
+func newLegacyTx(_ *big.Int, nonce uint64) *types.Transaction {
+	return types.NewTx(&types.LegacyTx{
+		To:       &receiverAddr,
+		Nonce:    nonce,
+		GasPrice: big.NewInt(1000000),
+		Gas:      50000,
+		Value:    big.NewInt(100),
+		Data:     []byte{1, 2, 3},
+	})
+}
+
+func newAccessListTx(chainID *big.Int, nonce uint64) *types.Transaction {
+	return types.NewTx(&types.AccessListTx{
+		ChainID:  chainID,
+		To:       &receiverAddr,
+		Nonce:    nonce,
+		GasPrice: big.NewInt(1000000),
+		Gas:      50000,
+		Value:    big.NewInt(100),
+		AccessList: types.AccessList{
+			{Address: receiverAddr, StorageKeys: []common.Hash{{1}}},
+		},
+	})
+}
+
+func newDynamicFeeTx(chainID *big.Int, nonce uint64) *types.Transaction {
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		To:        &receiverAddr,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1000000),
+		GasFeeCap: big.NewInt(2000000),
+		Gas:       50000,
+		Value:     big.NewInt(100),
+	})
+}
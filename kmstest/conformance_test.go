@@ -0,0 +1,20 @@
+package kmstest
+
+import (
+	"context"
+	kms "github.com/LampardNguyen234/evm-kms"
+	"github.com/LampardNguyen234/evm-kms/localkms"
+	"github.com/ethereum/go-ethereum/crypto"
+	"testing"
+)
+
+func TestRunConformance_Local(t *testing.T) {
+	RunConformance(t, func(t *testing.T) kms.KMSSigner {
+		privateKey, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return localkms.NewLocalKMSClientFromPrivateKey(context.Background(), privateKey, 1)
+	})
+}
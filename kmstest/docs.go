@@ -0,0 +1,5 @@
+// Package kmstest provides a conformance test suite for implementations of the kms.KMSSigner interface.
+//
+// Every backend, built-in or custom, is expected to pass RunConformance, usually against a fake server so that the
+// suite can run without any credentials or network access.
+package kmstest
@@ -0,0 +1,98 @@
+package localkms
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Config represents required information to create a local KMS client.
+//
+// Exactly one of PrivateKey, PrivateKeyEnv and PrivateKeyFile must be set.
+type Config struct {
+	// PrivateKey is the hex-encoded secp256k1 private key, with or without the `0x` prefix.
+	PrivateKey string `json:"PrivateKey,omitempty"`
+
+	// PrivateKeyEnv is the name of the environment variable holding the hex-encoded private key.
+	PrivateKeyEnv string `json:"PrivateKeyEnv,omitempty"`
+
+	// PrivateKeyFile is the path of the file holding the hex-encoded private key.
+	PrivateKeyFile string `json:"PrivateKeyFile,omitempty"`
+
+	// ChainID is the ID of the target EVM chain.
+	//
+	// See https://chainlist.org.
+	ChainID uint64 `json:"ChainID"`
+}
+
+// IsValid checks if a Config is valid.
+func (cfg Config) IsValid() (bool, error) {
+	count := 0
+	for _, source := range []string{cfg.PrivateKey, cfg.PrivateKeyEnv, cfg.PrivateKeyFile} {
+		if source != "" {
+			count++
+		}
+	}
+
+	if count == 0 {
+		return false, fmt.Errorf("empty PrivateKey, PrivateKeyEnv and PrivateKeyFile")
+	}
+	if count > 1 {
+		return false, fmt.Errorf("only one of PrivateKey, PrivateKeyEnv and PrivateKeyFile must be set")
+	}
+
+	return true, nil
+}
+
+// LoadConfigFromFile loads the config from the given config file.
+func LoadConfigFromFile(filePath string) (*Config, error) {
+	f, err := common2.ReadConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	err = json.Unmarshal(f, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = cfg.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// loadPrivateKey retrieves the private key from the source specified by the Config.
+func (cfg Config) loadPrivateKey() (*ecdsa.PrivateKey, error) {
+	var hexKey string
+	switch {
+	case cfg.PrivateKey != "":
+		hexKey = cfg.PrivateKey
+	case cfg.PrivateKeyEnv != "":
+		hexKey = os.Getenv(cfg.PrivateKeyEnv)
+		if hexKey == "" {
+			return nil, fmt.Errorf("environment variable %v is not set", cfg.PrivateKeyEnv)
+		}
+	case cfg.PrivateKeyFile != "":
+		f, err := ioutil.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read private key file: %v", err)
+		}
+		hexKey = string(f)
+	}
+
+	hexKey = strings.TrimPrefix(strings.TrimSpace(hexKey), "0x")
+	privateKey, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+
+	return privateKey, nil
+}
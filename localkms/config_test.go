@@ -0,0 +1,62 @@
+package localkms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestLoadConfigFromFile(t *testing.T) {
+	filePath := "./config-example.json"
+	cfg, err := LoadConfigFromFile(filePath)
+	if err != nil {
+		panic(err)
+	}
+	jsb, _ := json.MarshalIndent(cfg, "", "\t")
+	fmt.Println(string(jsb))
+}
+
+func TestConfig_loadPrivateKey(t *testing.T) {
+	hexKey := "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	expected := "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+
+	keyFile, err := os.CreateTemp("", "local-kms-key")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(keyFile.Name())
+	if _, err = keyFile.WriteString(hexKey + "\n"); err != nil {
+		panic(err)
+	}
+	_ = keyFile.Close()
+
+	os.Setenv("LOCAL_KMS_TEST_PRIVATE_KEY", hexKey[2:])
+	defer os.Unsetenv("LOCAL_KMS_TEST_PRIVATE_KEY")
+
+	for _, cfg := range []Config{
+		{PrivateKey: hexKey},
+		{PrivateKeyEnv: "LOCAL_KMS_TEST_PRIVATE_KEY"},
+		{PrivateKeyFile: keyFile.Name()},
+	} {
+		c, err := NewLocalKMSClient(context.Background(), cfg)
+		if err != nil {
+			panic(err)
+		}
+		if c.GetAddress().Hex() != expected {
+			panic(fmt.Sprintf("expected address %v, got %v", expected, c.GetAddress().Hex()))
+		}
+	}
+
+	for _, cfg := range []Config{
+		{},
+		{PrivateKey: hexKey, PrivateKeyFile: keyFile.Name()},
+		{PrivateKeyEnv: "LOCAL_KMS_TEST_UNSET_ENV"},
+		{PrivateKey: "0x1234"},
+	} {
+		if _, err = NewLocalKMSClient(context.Background(), cfg); err == nil {
+			panic(fmt.Sprintf("expected an error for config %+v", cfg))
+		}
+	}
+}
@@ -0,0 +1,6 @@
+// Package localkms provides a signing interface for EVM-compatible transactions backed by an in-memory secp256k1
+// private key.
+//
+// Unlike the other backends, the private key is held by the current process. It is intended for development and
+// testing purposes, where code paths taking a KMSSigner need to be exercised without any cloud credentials.
+package localkms
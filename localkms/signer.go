@@ -0,0 +1,153 @@
+package localkms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"math/big"
+)
+
+// LocalKMSClient implements the same functionalities as the remote KMS clients, using an in-memory private key.
+type LocalKMSClient struct {
+	privateKey *ecdsa.PrivateKey
+	ctx        context.Context
+	cfg        Config
+	signer     types.Signer
+}
+
+// NewLocalKMSClient creates a new local KMS client with the given config.
+//
+// If txSigner is not provided, the signer will be initiated as a types.NewLondonSigner(cfg.ChainID).
+// Note that only the first value of txSigner is used.
+//
+// Example:
+//
+//	cfg := Config{
+//		PrivateKeyEnv: "EVM_PRIVATE_KEY",
+//		ChainID:       1,
+//	}
+//
+//	c, err := NewLocalKMSClient(context.Background(), cfg)
+//	if err != nil {
+//		panic(err)
+//	}
+func NewLocalKMSClient(ctx context.Context, cfg Config, txSigner ...types.Signer) (*LocalKMSClient, error) {
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+
+	privateKey, err := cfg.loadPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLocalKMSClientFromPrivateKey(ctx, privateKey, cfg.ChainID, txSigner...), nil
+}
+
+// NewLocalKMSClientFromPrivateKey is an alternative of NewLocalKMSClient but uses the given private key directly.
+func NewLocalKMSClientFromPrivateKey(ctx context.Context,
+	privateKey *ecdsa.PrivateKey,
+	chainID uint64,
+	txSigner ...types.Signer,
+) *LocalKMSClient {
+	return &LocalKMSClient{
+		privateKey: privateKey,
+		ctx:        ctx,
+		cfg:        Config{ChainID: chainID},
+		signer:     common2.NewTxSigner(chainID, txSigner...),
+	}
+}
+
+// GetAddress returns the EVM address of the current signer.
+func (c LocalKMSClient) GetAddress() common.Address {
+	return crypto.PubkeyToAddress(c.privateKey.PublicKey)
+}
+
+// GetPublicKey returns the public key of the current signer.
+func (c LocalKMSClient) GetPublicKey() (*ecdsa.PublicKey, error) {
+	return &c.privateKey.PublicKey, nil
+}
+
+// SignHash signs the given digested message with the in-memory private key.
+func (c LocalKMSClient) SignHash(digest common.Hash) ([]byte, error) {
+	return c.SignHashWithContext(c.ctx, digest)
+}
+
+// SignHashWithContext is the same as SignHash, but returns an error if the given context is already done.
+func (c LocalKMSClient) SignHashWithContext(ctx context.Context, digest common.Hash) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sign digest: %w", err)
+	}
+
+	sig, err := crypto.Sign(digest[:], c.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign digest: %v", err)
+	}
+
+	return sig, nil
+}
+
+// SignTypedData signs the EIP-712 digest of the given typed data.
+// If legacyV is set to true, the returned v will be either 27 or 28 (as returned by `eth_signTypedData_v4`).
+func (c LocalKMSClient) SignTypedData(typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return c.SignTypedDataWithContext(c.ctx, typedData, legacyV)
+}
+
+// SignTypedDataWithContext is the same as SignTypedData, but returns an error if the given context is already done.
+func (c LocalKMSClient) SignTypedDataWithContext(ctx context.Context, typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return common2.SignTypedData(ctx, c.SignHashWithContext, typedData, legacyV)
+}
+
+// SignPersonalMessage signs the given message prefixed with "\x19Ethereum Signed Message:\n" and its length (EIP-191).
+// The returned v is either 27 or 28 (as returned by `personal_sign`).
+func (c LocalKMSClient) SignPersonalMessage(msg []byte) ([]byte, error) {
+	return c.SignPersonalMessageWithContext(c.ctx, msg)
+}
+
+// SignPersonalMessageWithContext is the same as SignPersonalMessage, but returns an error if the given context is already done.
+func (c LocalKMSClient) SignPersonalMessageWithContext(ctx context.Context, msg []byte) ([]byte, error) {
+	return common2.SignPersonalMessage(ctx, c.SignHashWithContext, msg)
+}
+
+// GetDefaultEVMTransactor returns the default instance of bind.TransactOpts.
+// Only `Context`, `From`, and `Signer` fields are set.
+func (c LocalKMSClient) GetDefaultEVMTransactor() *bind.TransactOpts {
+	return c.GetDefaultEVMTransactorWithContext(c.ctx)
+}
+
+// GetDefaultEVMTransactorWithContext is the same as GetDefaultEVMTransactor, but the given context is used for both
+// the transactor and its signer.
+func (c LocalKMSClient) GetDefaultEVMTransactorWithContext(ctx context.Context) *bind.TransactOpts {
+	return common2.NewTransactor(ctx, c.GetAddress(), c.GetEVMSignerFnWithContext(ctx))
+}
+
+// GetEVMSignerFn returns the EVM signer using the in-memory private key.
+func (c LocalKMSClient) GetEVMSignerFn() bind.SignerFn {
+	return c.GetEVMSignerFnWithContext(c.ctx)
+}
+
+// GetEVMSignerFnWithContext returns the EVM signer using the in-memory private key, bound to the given context.
+func (c LocalKMSClient) GetEVMSignerFnWithContext(ctx context.Context) bind.SignerFn {
+	return common2.NewSignerFn(ctx, c.GetAddress(), c.signer, c.SignHashWithContext)
+}
+
+// HasSignedTx checks if the given tx is signed by the current LocalKMSClient.
+func (c LocalKMSClient) HasSignedTx(tx *types.Transaction) (bool, error) {
+	return common2.HasSignedTx(c.signer, c.GetAddress(), tx)
+}
+
+// WithSigner assigns the given signer to the LocalKMSClient.
+func (c *LocalKMSClient) WithSigner(signer types.Signer) {
+	c.signer = signer
+}
+
+// WithChainID assigns given chainID (and updates the corresponding signer) to the LocalKMSClient.
+func (c *LocalKMSClient) WithChainID(chainID *big.Int) {
+	c.cfg.ChainID, c.signer = common2.SwitchChainID(c.cfg.ChainID, c.signer, chainID)
+}
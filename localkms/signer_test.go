@@ -0,0 +1,133 @@
+package localkms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+)
+
+var (
+	receiverAddr = common.HexToAddress("0x243e9517a24813a2d73e9a74cd2c1c699d0ff7a5")
+)
+
+var c *LocalKMSClient
+
+func init() {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+
+	c = NewLocalKMSClientFromPrivateKey(context.Background(), privateKey, 80001)
+}
+
+func TestLocalKMSClient_Sign(t *testing.T) {
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+	sig, err := c.SignHash(digest)
+	if err != nil {
+		panic(err)
+	}
+
+	pubKey, err := crypto.SigToPub(digest[:], sig)
+	if err != nil {
+		panic(err)
+	}
+	if crypto.PubkeyToAddress(*pubKey) != c.GetAddress() {
+		panic("invalid signature")
+	}
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = c.SignHashWithContext(cancelledCtx, digest); !errors.Is(err, context.Canceled) {
+		panic(fmt.Sprintf("expected %v, got %v", context.Canceled, err))
+	}
+}
+
+func TestLocalKMSClient_SignPersonalMessage(t *testing.T) {
+	msg := []byte("Hello World")
+	sig, err := c.SignPersonalMessage(msg)
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err = common2.VerifyPersonalMessage(c.GetAddress(), msg, sig); err != nil {
+		panic(err)
+	}
+}
+
+func TestLocalKMSClient_GetEVMSignerFn(t *testing.T) {
+	txs := []*types.Transaction{
+		types.NewTx(&types.LegacyTx{
+			To:       &receiverAddr,
+			Nonce:    1,
+			GasPrice: big.NewInt(1000000),
+			Gas:      50000,
+			Value:    big.NewInt(100),
+		}),
+		types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(80001),
+			To:        &receiverAddr,
+			Nonce:     2,
+			GasTipCap: big.NewInt(1000000),
+			GasFeeCap: big.NewInt(2000000),
+			Gas:       50000,
+			Value:     big.NewInt(100),
+		}),
+	}
+
+	for _, tx := range txs {
+		signedTx, err := c.GetDefaultEVMTransactor().Signer(c.GetAddress(), tx)
+		if err != nil {
+			panic(err)
+		}
+		if _, err = c.HasSignedTx(signedTx); err != nil {
+			panic(err)
+		}
+	}
+
+	_, err := c.GetEVMSignerFn()(receiverAddr, txs[0])
+	if err != bind.ErrNotAuthorized {
+		panic(fmt.Sprintf("expected %v, got %v", bind.ErrNotAuthorized, err))
+	}
+}
+
+func TestLocalKMSClient_WithChainID(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	tmpClient := NewLocalKMSClientFromPrivateKey(context.Background(), privateKey, 1)
+
+	tx := types.NewTx(&types.LegacyTx{
+		To:       &receiverAddr,
+		GasPrice: big.NewInt(1000000),
+		Gas:      50000,
+	})
+	signedTx, err := tmpClient.GetEVMSignerFn()(tmpClient.GetAddress(), tx)
+	if err != nil {
+		panic(err)
+	}
+	if signedTx.ChainId().Uint64() != 1 {
+		panic(fmt.Sprintf("expected chainID 1, got %v", signedTx.ChainId()))
+	}
+
+	tmpClient.WithChainID(big.NewInt(80001))
+	if _, err = tmpClient.HasSignedTx(signedTx); err == nil {
+		panic("expected an error for a tx signed with another chainID")
+	}
+
+	signedTx, err = tmpClient.GetEVMSignerFn()(tmpClient.GetAddress(), tx)
+	if err != nil {
+		panic(err)
+	}
+	if signedTx.ChainId().Uint64() != 80001 {
+		panic(fmt.Sprintf("expected chainID 80001, got %v", signedTx.ChainId()))
+	}
+}
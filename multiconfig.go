@@ -0,0 +1,205 @@
+package kms
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// signerConfigFields are the fields of a SignerConfig which are not backend config sections.
+var signerConfigFields = []string{"name", "chainIDs", "labels"}
+
+// SignerConfig is the config of a named signer of a MultiConfig. Besides its name, chain IDs and labels, it holds the
+// `type` of the signer and the config section of this type only.
+type SignerConfig struct {
+	// Name is the unique name of the signer.
+	Name string `json:"name"`
+
+	// ChainIDs are the IDs of the EVM chains the signer is used on. If set, the first one is assigned to the signer
+	// at creation, overriding the ChainID of the backend config section.
+	ChainIDs []uint64 `json:"chainIDs,omitempty"`
+
+	// Labels are arbitrary labels of the signer (e.g, {"role": "relayer"}).
+	Labels map[string]string `json:"labels,omitempty"`
+
+	Config
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (cfg *SignerConfig) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Name     string            `json:"name"`
+		ChainIDs []uint64          `json:"chainIDs"`
+		Labels   map[string]string `json:"labels"`
+	}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	var backendCfg Config
+	if err := json.Unmarshal(data, &backendCfg); err != nil {
+		return err
+	}
+	for _, field := range signerConfigFields {
+		delete(backendCfg.RawConfigs, field)
+	}
+
+	*cfg = SignerConfig{Name: tmp.Name, ChainIDs: tmp.ChainIDs, Labels: tmp.Labels, Config: backendCfg}
+	return nil
+}
+
+// IsValid checks if the current SignerConfig is valid. Besides the config section of its type, a SignerConfig must
+// not have any other config section.
+func (cfg SignerConfig) IsValid() (bool, error) {
+	if cfg.Name == "" {
+		return false, fmt.Errorf("empty signer name")
+	}
+
+	for section := range cfg.RawConfigs {
+		if !strings.EqualFold(section, cfg.Type) {
+			return false, fmt.Errorf("signer `%v`: unexpected `%v` section for a `%v` signer", cfg.Name, section, cfg.Type)
+		}
+	}
+
+	for _, chainID := range cfg.ChainIDs {
+		if chainID == 0 {
+			return false, fmt.Errorf("signer `%v`: invalid chainID 0", cfg.Name)
+		}
+	}
+
+	if _, err := cfg.Config.IsValid(); err != nil {
+		return false, fmt.Errorf("signer `%v`: %v", cfg.Name, err)
+	}
+
+	return true, nil
+}
+
+// HasChainID checks if the signer is used on the given chain. A signer without ChainIDs is used on any chain.
+func (cfg SignerConfig) HasChainID(chainID uint64) bool {
+	if len(cfg.ChainIDs) == 0 {
+		return true
+	}
+
+	for _, id := range cfg.ChainIDs {
+		if id == chainID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MultiConfig declares many named signers, possibly of different types.
+//
+// Example:
+//
+//	{
+//	  "signers": [
+//	    {"name": "relayer", "type": "aws", "chainIDs": [1, 137], "aws": {...}},
+//	    {"name": "treasury", "type": "gcp", "labels": {"team": "finance"}, "gcp": {...}}
+//	  ]
+//	}
+type MultiConfig struct {
+	// Signers are the configs of the signers.
+	Signers []SignerConfig `json:"signers"`
+}
+
+// IsValid checks if the current MultiConfig is valid.
+func (cfg MultiConfig) IsValid() (bool, error) {
+	if len(cfg.Signers) == 0 {
+		return false, fmt.Errorf("empty signers")
+	}
+
+	names := make(map[string]bool)
+	for _, signerCfg := range cfg.Signers {
+		if _, err := signerCfg.IsValid(); err != nil {
+			return false, err
+		}
+		if names[signerCfg.Name] {
+			return false, fmt.Errorf("duplicate signer name `%v`", signerCfg.Name)
+		}
+		names[signerCfg.Name] = true
+	}
+
+	return true, nil
+}
+
+// SignerConfigs returns the configs of the signers, keyed by their names.
+func (cfg MultiConfig) SignerConfigs() map[string]SignerConfig {
+	res := make(map[string]SignerConfig, len(cfg.Signers))
+	for _, signerCfg := range cfg.Signers {
+		res[signerCfg.Name] = signerCfg
+	}
+
+	return res
+}
+
+// NewKMSSignersFromConfig creates and returns the KMSSigners of the given MultiConfig, keyed by their names.
+func NewKMSSignersFromConfig(cfg MultiConfig) (map[string]KMSSigner, error) {
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	res := make(map[string]KMSSigner, len(cfg.Signers))
+	for _, signerCfg := range cfg.Signers {
+		signer, err := NewKMSSignerFromConfig(signerCfg.Config)
+		if err != nil {
+			closeSigners(res)
+			return nil, fmt.Errorf("cannot create signer `%v`: %w", signerCfg.Name, err)
+		}
+		if len(signerCfg.ChainIDs) > 0 {
+			signer.WithChainID(new(big.Int).SetUint64(signerCfg.ChainIDs[0]))
+		}
+		res[signerCfg.Name] = signer
+	}
+
+	return res, nil
+}
+
+// closeSigners closes the given signers which hold resources (e.g, connections or sessions), i.e. implement io.Closer.
+func closeSigners(signers map[string]KMSSigner) {
+	for _, signer := range signers {
+		if closer, ok := signer.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
+
+// NewKMSSignersFromConfigFile creates and returns the KMSSigners of the given multi-signer config file (JSON, YAML or
+// TOML), keyed by their names.
+func NewKMSSignersFromConfigFile(filePath string) (map[string]KMSSigner, error) {
+	cfg, err := LoadMultiConfigFromFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKMSSignersFromConfig(*cfg)
+}
+
+// LoadMultiConfigFromJSONFile creates a MultiConfig from the given json config file, whatever its extension.
+// See LoadMultiConfigFromFile for more detail.
+func LoadMultiConfigFromJSONFile(filePath string) (*MultiConfig, error) {
+	return loadMultiConfigFromFile(filePath, formatJSON)
+}
+
+// LoadMultiConfig creates a MultiConfig from the given raw config data.
+func LoadMultiConfig(rawConfig map[string]interface{}) (*MultiConfig, error) {
+	jsb, err := json.Marshal(rawConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg MultiConfig
+	err = json.Unmarshal(jsb, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = cfg.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
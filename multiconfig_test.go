@@ -0,0 +1,191 @@
+package kms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLoadMultiConfigFromJSONFile(t *testing.T) {
+	cfg, err := LoadMultiConfigFromJSONFile("config-multi-example.json")
+	if err != nil {
+		panic(err)
+	}
+
+	signerConfigs := cfg.SignerConfigs()
+	if len(signerConfigs) != 2 {
+		panic(fmt.Sprintf("expected 2 signers, got %v", len(signerConfigs)))
+	}
+
+	relayer, ok := signerConfigs["relayer"]
+	if !ok || relayer.Type != awsType || relayer.AwsConfig.KeyID != "KEY_ID" || relayer.Labels["role"] != "relayer" {
+		panic(fmt.Sprintf("invalid relayer config %v", relayer))
+	}
+	if !relayer.HasChainID(137) || relayer.HasChainID(5) {
+		panic(fmt.Sprintf("invalid relayer chainIDs %v", relayer.ChainIDs))
+	}
+
+	treasury, ok := signerConfigs["treasury"]
+	if !ok || treasury.Type != gcpType || treasury.GcpConfig.ProjectID != "evm-kms" || !treasury.HasChainID(5) {
+		panic(fmt.Sprintf("invalid treasury config %v", treasury))
+	}
+
+	// a single-signer config is rejected
+	if _, err = LoadConfig(map[string]interface{}{"signers": []interface{}{}}); err == nil {
+		panic("expected an error for a multi-signer config")
+	}
+}
+
+func TestNewKMSSignersFromConfig(t *testing.T) {
+	cfg, err := LoadMultiConfig(map[string]interface{}{
+		"signers": []interface{}{
+			map[string]interface{}{
+				"name":     "relayer",
+				"type":     "local",
+				"chainIDs": []uint64{80001},
+				"local": map[string]interface{}{
+					"PrivateKey": "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",
+					"ChainID":    1,
+				},
+			},
+			map[string]interface{}{
+				"name": "treasury",
+				"type": "local",
+				"local": map[string]interface{}{
+					"PrivateKey": "0x8f2a55949038a9610f50fb23b5883af3b4ecb3c3bb792cbcefbd1542c692be63",
+					"ChainID":    1,
+				},
+			},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	signers, err := NewKMSSignersFromConfig(*cfg)
+	if err != nil {
+		panic(err)
+	}
+	if len(signers) != 2 {
+		panic(fmt.Sprintf("expected 2 signers, got %v", len(signers)))
+	}
+
+	expected := "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	if signers["relayer"].GetAddress().Hex() != expected {
+		panic(fmt.Sprintf("expected address %v, got %v", expected, signers["relayer"].GetAddress().Hex()))
+	}
+	if signers["relayer"].GetDefaultEVMTransactor().Signer == nil {
+		panic("expected a signer function")
+	}
+}
+
+// closerSigner is a KMSSigner which records whether it has been closed.
+type closerSigner struct {
+	KMSSigner
+	closed bool
+}
+
+func (s *closerSigner) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestNewKMSSignersFromConfig_CloseOnError(t *testing.T) {
+	type closerConfig struct {
+		Fail bool `json:"Fail"`
+	}
+
+	errTest := fmt.Errorf("closer backend")
+	var created []*closerSigner
+	t.Cleanup(func() { unregisterBackend("closer-kms") })
+	RegisterBackend("closer-kms", func(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error) {
+		var cfg closerConfig
+		if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+			return nil, err
+		}
+		if cfg.Fail {
+			return nil, errTest
+		}
+		signer := &closerSigner{KMSSigner: newLocalSigner()}
+		created = append(created, signer)
+		return signer, nil
+	})
+
+	cfg, err := LoadMultiConfig(map[string]interface{}{
+		"signers": []interface{}{
+			map[string]interface{}{"name": "first", "type": "closer-kms", "closer-kms": map[string]interface{}{}},
+			map[string]interface{}{"name": "second", "type": "closer-kms", "closer-kms": map[string]interface{}{}},
+			map[string]interface{}{"name": "third", "type": "closer-kms", "closer-kms": map[string]interface{}{"Fail": true}},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = NewKMSSignersFromConfig(*cfg)
+	if !errors.Is(err, errTest) {
+		panic(fmt.Sprintf("expected error %v, got %v", errTest, err))
+	}
+	if len(created) != 2 {
+		panic(fmt.Sprintf("expected 2 created signers, got %v", len(created)))
+	}
+	for i, signer := range created {
+		if !signer.closed {
+			panic(fmt.Sprintf("signer %v has not been closed", i))
+		}
+	}
+}
+
+func TestMultiConfig_IsValid(t *testing.T) {
+	localSection := map[string]interface{}{
+		"PrivateKey": "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",
+		"ChainID":    1,
+	}
+
+	testCases := []struct {
+		name    string
+		signers []interface{}
+	}{
+		{
+			name:    "no signers",
+			signers: []interface{}{},
+		},
+		{
+			name:    "empty name",
+			signers: []interface{}{map[string]interface{}{"type": "local", "local": localSection}},
+		},
+		{
+			name: "duplicate names",
+			signers: []interface{}{
+				map[string]interface{}{"name": "a", "type": "local", "local": localSection},
+				map[string]interface{}{"name": "a", "type": "local", "local": localSection},
+			},
+		},
+		{
+			name: "section of another type",
+			signers: []interface{}{map[string]interface{}{
+				"name": "a", "type": "local", "local": localSection, "aws": map[string]interface{}{"KeyID": "KEY_ID"},
+			}},
+		},
+		{
+			name: "zero chainID",
+			signers: []interface{}{map[string]interface{}{
+				"name": "a", "type": "local", "chainIDs": []uint64{0}, "local": localSection,
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := LoadMultiConfig(map[string]interface{}{"signers": tc.signers})
+		if err == nil {
+			panic(fmt.Sprintf("%v: expected an error", tc.name))
+		}
+	}
+
+	_, err := NewKMSSignersFromConfig(MultiConfig{})
+	if !errors.Is(err, ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig, got %v", err))
+	}
+}
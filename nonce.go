@@ -0,0 +1,230 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxNonceAttempts is the maximum number of attempts of NonceManager.Transact when the nonce is rejected.
+const maxNonceAttempts = 3
+
+// nonceErrors are the (lowercase) messages of the errors returned by the nodes when a nonce has already been used.
+var nonceErrors = []string{
+	"nonce too low",
+	"replacement transaction underpriced",
+}
+
+// NonceBackend is the subset of an ethclient.Client required by a NonceManager.
+type NonceBackend interface {
+	// PendingNonceAt returns the account nonce of the given account in the pending state.
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out sequential nonces for the address of a KMSSigner, so that many transactions can be signed and
+// sent concurrently from this address. It is reconciled with the pending nonce of the backend at creation, and
+// whenever a node reports that a nonce has already been used.
+//
+// A NonceManager must be the only sender of transactions from its address; otherwise, Sync must be called.
+// A NonceManager is safe for concurrent use.
+type NonceManager struct {
+	signer  KMSSigner
+	backend NonceBackend
+
+	mtx      sync.Mutex
+	next     uint64
+	released []uint64
+
+	// stale indicates whether a transaction may have been sent outside of the NonceManager's knowledge (e.g, after a
+	// transport error), so that it must be synced before handing out the next nonce via Transact.
+	stale bool
+}
+
+// NewNonceManager creates a new NonceManager for the address of the given KMSSigner, starting at the pending nonce of
+// this address.
+func NewNonceManager(ctx context.Context, signer KMSSigner, backend NonceBackend) (*NonceManager, error) {
+	m := &NonceManager{signer: signer, backend: backend}
+	if err := m.Reset(ctx); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Address returns the address whose nonces are managed.
+func (m *NonceManager) Address() common.Address {
+	return m.signer.GetAddress()
+}
+
+// Acquire returns the next nonce to use. The lowest released nonce is reused first, so that no gap is left.
+// The nonce must be given back via Release if no transaction is sent with it.
+func (m *NonceManager) Acquire() uint64 {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if len(m.released) > 0 {
+		nonce := m.released[0]
+		m.released = m.released[1:]
+		return nonce
+	}
+
+	nonce := m.next
+	m.next++
+
+	return nonce
+}
+
+// Release gives back a nonce returned by Acquire, which has not been used by a sent transaction (e.g, because
+// signing failed).
+func (m *NonceManager) Release(nonce uint64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if nonce >= m.next {
+		return
+	}
+
+	i := sort.Search(len(m.released), func(i int) bool { return m.released[i] >= nonce })
+	if i < len(m.released) && m.released[i] == nonce {
+		return
+	}
+	m.released = append(m.released, 0)
+	copy(m.released[i+1:], m.released[i:])
+	m.released[i] = nonce
+
+	// shrink the counter instead of keeping released nonces at the end of the range
+	for len(m.released) > 0 && m.released[len(m.released)-1] == m.next-1 {
+		m.released = m.released[:len(m.released)-1]
+		m.next--
+	}
+}
+
+// Sync reconciles the NonceManager with the pending nonce of the backend, which is required when transactions have
+// been sent from the address by another sender. Nonces lower than the pending nonce are never handed out again.
+func (m *NonceManager) Sync(ctx context.Context) error {
+	pendingNonce, err := m.backend.PendingNonceAt(ctx, m.Address())
+	if err != nil {
+		return fmt.Errorf("cannot get the pending nonce of %v: %v", m.Address(), err)
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if pendingNonce > m.next {
+		m.next = pendingNonce
+	}
+	i := sort.Search(len(m.released), func(i int) bool { return m.released[i] >= pendingNonce })
+	m.released = m.released[i:]
+	m.stale = false
+
+	return nil
+}
+
+// Reset sets the next nonce to the pending nonce of the backend and forgets the released nonces. Unlike Sync, the
+// counter can go backwards (e.g, after pending transactions have been dropped), so it must not be called while
+// transactions are in flight.
+func (m *NonceManager) Reset(ctx context.Context) error {
+	pendingNonce, err := m.backend.PendingNonceAt(ctx, m.Address())
+	if err != nil {
+		return fmt.Errorf("cannot get the pending nonce of %v: %v", m.Address(), err)
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.next = pendingNonce
+	m.released = nil
+	m.stale = false
+
+	return nil
+}
+
+// Transact acquires a nonce and calls fn with a KMS-backed bind.TransactOpts using it (e.g, to call a method of a
+// contract binding). Only `Context`, `From`, `Nonce` and `Signer` fields are set.
+//
+// If fn fails before the transaction is signed (e.g, a signing error or bind.ErrNotAuthorized), the nonce is
+// released. If the error reports that the nonce has already been used (e.g, "nonce too low"), the NonceManager is
+// synced with the backend and fn is retried with a new nonce, up to 3 attempts. Otherwise, the transaction may have
+// been sent (e.g, on a transport error), so the nonce is not reused and the NonceManager is synced with the backend
+// before the next call of Transact.
+func (m *NonceManager) Transact(ctx context.Context,
+	fn func(opts *bind.TransactOpts) (*types.Transaction, error),
+) (*types.Transaction, error) {
+	var err error
+	for attempt := 0; attempt < maxNonceAttempts; attempt++ {
+		if m.isStale() {
+			if err = m.Sync(ctx); err != nil {
+				return nil, err
+			}
+		}
+		nonce := m.Acquire()
+
+		signerFn := m.signer.GetEVMSignerFnWithContext(ctx)
+		signed := false
+		var tx *types.Transaction
+		tx, err = fn(&bind.TransactOpts{
+			Context: ctx,
+			From:    m.Address(),
+			Nonce:   new(big.Int).SetUint64(nonce),
+			Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+				signedTx, err := signerFn(address, tx)
+				if err == nil {
+					signed = true
+				}
+				return signedTx, err
+			},
+		})
+		if err == nil {
+			return tx, nil
+		}
+
+		if !isNonceError(err) {
+			if signed {
+				m.markStale()
+			} else {
+				m.Release(nonce)
+			}
+			return nil, err
+		}
+		m.Release(nonce)
+		if syncErr := m.Sync(ctx); syncErr != nil {
+			return nil, fmt.Errorf("%w; %v", err, syncErr)
+		}
+	}
+
+	return nil, err
+}
+
+// isStale checks if the NonceManager must be synced before handing out the next nonce.
+func (m *NonceManager) isStale() bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return m.stale
+}
+
+// markStale marks the NonceManager to be synced before handing out the next nonce.
+func (m *NonceManager) markStale() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.stale = true
+}
+
+// isNonceError checks if the given error reports that the nonce has already been used.
+func isNonceError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, nonceErr := range nonceErrors {
+		if strings.Contains(msg, nonceErr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,198 @@
+package kms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+// nonceBackend is a NonceBackend with a settable pending nonce.
+type nonceBackend struct {
+	mtx          sync.Mutex
+	pendingNonce uint64
+}
+
+func (b *nonceBackend) PendingNonceAt(_ context.Context, _ common.Address) (uint64, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return b.pendingNonce, nil
+}
+
+func (b *nonceBackend) setPendingNonce(nonce uint64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.pendingNonce = nonce
+}
+
+func TestNonceManager_Acquire(t *testing.T) {
+	ctx := context.Background()
+	backend := &nonceBackend{pendingNonce: 5}
+	m, err := NewNonceManager(ctx, newLocalSigner(), backend)
+	if err != nil {
+		panic(err)
+	}
+
+	numNonces := 100
+	nonces := make(chan uint64, numNonces)
+	var wg sync.WaitGroup
+	for i := 0; i < numNonces; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonces <- m.Acquire()
+		}()
+	}
+	wg.Wait()
+	close(nonces)
+
+	seen := make(map[uint64]bool)
+	for nonce := range nonces {
+		if nonce < 5 || nonce >= uint64(5+numNonces) || seen[nonce] {
+			panic(fmt.Sprintf("invalid or duplicate nonce %v", nonce))
+		}
+		seen[nonce] = true
+	}
+
+	// released nonces are reused first, lowest first
+	m.Release(10)
+	m.Release(7)
+	if nonce := m.Acquire(); nonce != 7 {
+		panic(fmt.Sprintf("expected nonce 7, got %v", nonce))
+	}
+	if nonce := m.Acquire(); nonce != 10 {
+		panic(fmt.Sprintf("expected nonce 10, got %v", nonce))
+	}
+
+	// releasing the last nonce shrinks the counter
+	last := m.Acquire()
+	m.Release(last)
+	if nonce := m.Acquire(); nonce != last {
+		panic(fmt.Sprintf("expected nonce %v, got %v", last, nonce))
+	}
+
+	// syncing never goes backwards, resetting does
+	backend.setPendingNonce(200)
+	if err = m.Sync(ctx); err != nil {
+		panic(err)
+	}
+	if nonce := m.Acquire(); nonce != 200 {
+		panic(fmt.Sprintf("expected nonce 200, got %v", nonce))
+	}
+	backend.setPendingNonce(150)
+	if err = m.Sync(ctx); err != nil {
+		panic(err)
+	}
+	if nonce := m.Acquire(); nonce != 201 {
+		panic(fmt.Sprintf("expected nonce 201, got %v", nonce))
+	}
+	if err = m.Reset(ctx); err != nil {
+		panic(err)
+	}
+	if nonce := m.Acquire(); nonce != 150 {
+		panic(fmt.Sprintf("expected nonce 150, got %v", nonce))
+	}
+}
+
+func TestNonceManager_Transact(t *testing.T) {
+	ctx := context.Background()
+	signer := newLocalSigner()
+	backend := &nonceBackend{}
+	m, err := NewNonceManager(ctx, signer, backend)
+	if err != nil {
+		panic(err)
+	}
+
+	send := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(80001),
+			Nonce:     opts.Nonce.Uint64(),
+			GasTipCap: big.NewInt(1e9),
+			GasFeeCap: big.NewInt(1e10),
+			Gas:       21000,
+			To:        &common.Address{},
+			Value:     big.NewInt(1),
+		})
+		return opts.Signer(opts.From, tx)
+	}
+
+	tx, err := m.Transact(ctx, send)
+	if err != nil {
+		panic(err)
+	}
+	if tx.Nonce() != 0 {
+		panic(fmt.Sprintf("expected nonce 0, got %v", tx.Nonce()))
+	}
+
+	// a transaction failing before signing releases its nonce
+	_, err = m.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return nil, fmt.Errorf("insufficient funds for gas * price + value")
+	})
+	if err == nil {
+		panic("expected an error")
+	}
+	_, err = m.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return opts.Signer(common.Address{}, types.NewTx(&types.LegacyTx{Nonce: opts.Nonce.Uint64()}))
+	})
+	if !errors.Is(err, bind.ErrNotAuthorized) {
+		panic(fmt.Sprintf("expected ErrNotAuthorized, got %v", err))
+	}
+	tx, err = m.Transact(ctx, send)
+	if err != nil {
+		panic(err)
+	}
+	if tx.Nonce() != 1 {
+		panic(fmt.Sprintf("expected nonce 1, got %v", tx.Nonce()))
+	}
+
+	// a transaction failing after signing may have been sent, so its nonce is not reused, and the next nonce is synced
+	_, err = m.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		if _, err := send(opts); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("connection reset by peer")
+	})
+	if err == nil {
+		panic("expected an error")
+	}
+	backend.setPendingNonce(5)
+	tx, err = m.Transact(ctx, send)
+	if err != nil {
+		panic(err)
+	}
+	if tx.Nonce() != 5 {
+		panic(fmt.Sprintf("expected nonce 5, got %v", tx.Nonce()))
+	}
+
+	// "nonce too low" syncs the nonce and retries
+	backend.setPendingNonce(10)
+	attempts := 0
+	tx, err = m.Transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		attempts++
+		if opts.Nonce.Uint64() < 10 {
+			return nil, fmt.Errorf("nonce too low: next nonce 10, tx nonce %v", opts.Nonce)
+		}
+		return send(opts)
+	})
+	if err != nil {
+		panic(err)
+	}
+	if tx.Nonce() != 10 || attempts != 2 {
+		panic(fmt.Sprintf("expected nonce 10 after 2 attempts, got %v after %v", tx.Nonce(), attempts))
+	}
+
+	from, err := types.Sender(types.NewLondonSigner(big.NewInt(80001)), tx)
+	if err != nil {
+		panic(err)
+	}
+	if from != signer.GetAddress() {
+		panic(fmt.Sprintf("expected sender %v, got %v", signer.GetAddress(), from))
+	}
+}
@@ -0,0 +1,98 @@
+package pkcs11kms
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"os"
+)
+
+// Config represents required information to create a PKCS#11 client.
+//
+// The token is selected by either TokenLabel or SlotID, and the key by KeyLabel and/or KeyID.
+type Config struct {
+	// ModulePath is the path of the PKCS#11 library of the token.
+	//
+	// Example: "/usr/lib/softhsm/libsofthsm2.so".
+	ModulePath string `json:"ModulePath"`
+
+	// TokenLabel is the label of the token holding the key.
+	TokenLabel string `json:"TokenLabel,omitempty"`
+
+	// SlotID is the ID of the slot holding the token. It is only used if TokenLabel is empty.
+	SlotID *uint `json:"SlotID,omitempty"`
+
+	// PIN is the user PIN of the token.
+	//
+	// Leave this field empty if the environment variable `PKCS11_PIN` has been set.
+	PIN string `json:"PIN,omitempty"`
+
+	// KeyLabel is the label (CKA_LABEL) of the key.
+	KeyLabel string `json:"KeyLabel,omitempty"`
+
+	// KeyID is the hex-encoded ID (CKA_ID) of the key.
+	KeyID string `json:"KeyID,omitempty"`
+
+	// ChainID is the ID of the target EVM chain.
+	//
+	// See https://chainlist.org.
+	ChainID uint64 `json:"ChainID"`
+}
+
+// IsValid checks if a Config is valid. An empty PIN is valid if the environment variable `PKCS11_PIN` is set.
+func (cfg Config) IsValid() (bool, error) {
+	cfg = cfg.withEnvDefaults()
+
+	if cfg.ModulePath == "" {
+		return false, fmt.Errorf("empty ModulePath")
+	}
+
+	if cfg.TokenLabel == "" && cfg.SlotID == nil {
+		return false, fmt.Errorf("empty TokenLabel and SlotID")
+	}
+
+	if cfg.PIN == "" {
+		return false, fmt.Errorf("empty PIN")
+	}
+
+	if cfg.KeyLabel == "" && cfg.KeyID == "" {
+		return false, fmt.Errorf("empty KeyLabel and KeyID")
+	}
+
+	if _, err := hex.DecodeString(cfg.KeyID); err != nil {
+		return false, fmt.Errorf("invalid KeyID: %v", err)
+	}
+
+	return true, nil
+}
+
+// LoadConfigFromFile loads the config from the given config file.
+func LoadConfigFromFile(filePath string) (*Config, error) {
+	f, err := common2.ReadConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	err = json.Unmarshal(f, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg = cfg.withEnvDefaults()
+	if _, err = cfg.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// withEnvDefaults returns a copy of the Config whose empty PIN is taken from the `PKCS11_PIN` environment variable.
+func (cfg Config) withEnvDefaults() Config {
+	if cfg.PIN == "" {
+		cfg.PIN = os.Getenv("PKCS11_PIN")
+	}
+
+	return cfg
+}
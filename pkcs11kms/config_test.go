@@ -0,0 +1,39 @@
+package pkcs11kms
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestLoadConfigFromFile(t *testing.T) {
+	filePath := "./config-example.json"
+	cfg, err := LoadConfigFromFile(filePath)
+	if err != nil {
+		panic(err)
+	}
+	jsb, _ := json.MarshalIndent(cfg, "", "\t")
+	fmt.Println(string(jsb))
+}
+
+func TestConfig_IsValid_Env(t *testing.T) {
+	cfg := Config{
+		ModulePath: "/usr/lib/softhsm/libsofthsm2.so",
+		TokenLabel: "evm-kms",
+		KeyLabel:   "evm-ecdsa",
+		ChainID:    1,
+	}
+	if _, err := cfg.IsValid(); err == nil {
+		panic("expected an error with an empty PIN")
+	}
+
+	_ = os.Setenv("PKCS11_PIN", "1234")
+	defer os.Unsetenv("PKCS11_PIN")
+	if _, err := cfg.IsValid(); err != nil {
+		panic(err)
+	}
+	if cfg.withEnvDefaults().PIN != "1234" {
+		panic(fmt.Sprintf("expected PIN 1234, got %v", cfg.withEnvDefaults().PIN))
+	}
+}
@@ -0,0 +1,9 @@
+// Package pkcs11kms uses a PKCS#11 token (e.g, a network or USB HSM, or SoftHSMv2 for testing) to provide a signing
+// interface for EVM-compatible transactions.
+//
+// Rather than directly accessing a private key to sign a transaction, the client asks the token to do so via the
+// CKM_ECDSA mechanism and the private key never leaves the token. The key must be an EC key on the secp256k1 curve.
+//
+// This package requires cgo. The root package registers it as the `pkcs11` backend of kms.NewKMSSignerFromConfig
+// only when built with cgo enabled.
+package pkcs11kms
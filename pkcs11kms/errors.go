@@ -0,0 +1,37 @@
+package pkcs11kms
+
+import (
+	"errors"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/miekg/pkcs11"
+)
+
+// wrapError wraps an error returned by the PKCS#11 module into a common2.KMSError, whose Kind is derived from the
+// PKCS#11 return value (CKR_*).
+func wrapError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return common2.NewKMSError(op, errorKind(err), err)
+}
+
+// errorKind maps an error returned by the PKCS#11 module to one of the sentinel errors of the common package.
+func errorKind(err error) error {
+	var p11Err pkcs11.Error
+	if !errors.As(err, &p11Err) {
+		return nil
+	}
+
+	switch p11Err {
+	case pkcs11.CKR_PIN_INCORRECT, pkcs11.CKR_PIN_INVALID, pkcs11.CKR_PIN_EXPIRED, pkcs11.CKR_PIN_LOCKED,
+		pkcs11.CKR_USER_NOT_LOGGED_IN:
+		return common2.ErrPermissionDenied
+	case pkcs11.CKR_KEY_HANDLE_INVALID, pkcs11.CKR_OBJECT_HANDLE_INVALID, pkcs11.CKR_TOKEN_NOT_PRESENT:
+		return common2.ErrKeyNotFound
+	case pkcs11.CKR_KEY_TYPE_INCONSISTENT, pkcs11.CKR_KEY_FUNCTION_NOT_PERMITTED, pkcs11.CKR_MECHANISM_INVALID:
+		return common2.ErrWrongKeySpec
+	}
+
+	return nil
+}
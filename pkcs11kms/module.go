@@ -0,0 +1,76 @@
+package pkcs11kms
+
+import (
+	"fmt"
+	"github.com/miekg/pkcs11"
+	"sync"
+)
+
+var (
+	// modules are the PKCS#11 modules loaded by the clients, indexed by their paths.
+	modules    = make(map[string]*module)
+	modulesMtx sync.Mutex
+)
+
+// module is a PKCS#11 module shared by all the clients of the same ModulePath. A module can only be initialized once
+// per process, so it is loaded by its first client and finalized when its last client is closed.
+type module struct {
+	path   string
+	p11Ctx *pkcs11.Ctx
+	refs   int
+
+	// finalize indicates whether the module has been initialized by this package (as opposed to another library of
+	// the process), and thus must be finalized by it.
+	finalize bool
+}
+
+// openModule returns the module of the given path, loading and initializing it if needed. Each call must be paired
+// with a call to release.
+func openModule(path string) (*module, error) {
+	modulesMtx.Lock()
+	defer modulesMtx.Unlock()
+
+	if m, ok := modules[path]; ok {
+		m.refs++
+		return m, nil
+	}
+
+	p11Ctx := pkcs11.New(path)
+	if p11Ctx == nil {
+		return nil, fmt.Errorf("cannot load PKCS#11 module %v", path)
+	}
+
+	finalize := true
+	if err := p11Ctx.Initialize(); err != nil {
+		if err != pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+			p11Ctx.Destroy()
+			return nil, fmt.Errorf("cannot initialize PKCS#11 module: %v", err)
+		}
+		finalize = false
+	}
+
+	m := &module{path: path, p11Ctx: p11Ctx, refs: 1, finalize: finalize}
+	modules[path] = m
+
+	return m, nil
+}
+
+// release releases a reference to the module, and finalizes and unloads it if this was the last one.
+func (m *module) release() error {
+	modulesMtx.Lock()
+	defer modulesMtx.Unlock()
+
+	m.refs--
+	if m.refs > 0 {
+		return nil
+	}
+	delete(modules, m.path)
+
+	var err error
+	if m.finalize {
+		err = m.p11Ctx.Finalize()
+	}
+	m.p11Ctx.Destroy()
+
+	return err
+}
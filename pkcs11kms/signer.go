@@ -0,0 +1,362 @@
+package pkcs11kms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/miekg/pkcs11"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// PKCS11KMSClient implements basic functionalities of a PKCS#11 client for signing transactions.
+//
+// A PKCS11KMSClient holds an open session to the token; call Close to release it.
+type PKCS11KMSClient struct {
+	session   *session
+	ctx       context.Context
+	cfg       Config
+	publicKey *ecdsa.PublicKey
+	signer    types.Signer
+}
+
+// session holds the PKCS#11 session shared by all copies of a PKCS11KMSClient.
+type session struct {
+	// mtx serializes the operations on the session, which must not be used concurrently.
+	mtx        sync.Mutex
+	module     *module
+	p11Ctx     *pkcs11.Ctx
+	handle     pkcs11.SessionHandle
+	privateKey pkcs11.ObjectHandle
+}
+
+// NewPKCS11KMSClient creates a new PKCS#11 client with the given config. It loads the PKCS#11 module, opens a
+// session to the token, logs in and looks up the key. The module is shared by all the clients of the same
+// ModulePath, and unloaded when the last of them is closed.
+//
+// If txSigner is not provided, the signer will be initiated as a types.NewLondonSigner(cfg.ChainID).
+// Note that only the first value of txSigner is used.
+//
+// Example:
+//
+//	cfg := Config{
+//		ModulePath: "/usr/lib/softhsm/libsofthsm2.so",
+//		TokenLabel: "evm-kms",
+//		PIN:        "1234",
+//		KeyLabel:   "evm-ecdsa",
+//		ChainID:    1,
+//	}
+//
+//	c, err := NewPKCS11KMSClient(context.Background(), cfg)
+//	if err != nil {
+//		panic(err)
+//	}
+//	defer c.Close()
+func NewPKCS11KMSClient(ctx context.Context, cfg Config, txSigner ...types.Signer) (*PKCS11KMSClient, error) {
+	cfg = cfg.withEnvDefaults()
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+
+	signer := common2.NewTxSigner(cfg.ChainID, txSigner...)
+
+	m, err := openModule(cfg.ModulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &PKCS11KMSClient{session: &session{module: m, p11Ctx: m.p11Ctx}, ctx: ctx, cfg: cfg, signer: signer}
+	if err := c.init(); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Close closes the session, and unloads the PKCS#11 module if no other client uses it. The token is logged out when
+// its last session is closed.
+func (c PKCS11KMSClient) Close() error {
+	s := c.session
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.p11Ctx == nil {
+		return nil
+	}
+
+	// the login state is shared by all the sessions of the token, so logging out would also log out the other
+	// clients.
+	if s.handle != 0 {
+		_ = s.p11Ctx.CloseSession(s.handle)
+	}
+	s.p11Ctx = nil
+
+	return s.module.release()
+}
+
+// GetAddress returns the EVM address of the current signer.
+func (c PKCS11KMSClient) GetAddress() common.Address {
+	return crypto.PubkeyToAddress(*c.publicKey)
+}
+
+// GetPublicKey returns the public key read from the token.
+func (c PKCS11KMSClient) GetPublicKey() (*ecdsa.PublicKey, error) {
+	return c.publicKey, nil
+}
+
+// SignHash asks the token to sign a given digested message with the CKM_ECDSA mechanism.
+// The CKM_ECDSA mechanism signs the given digest as is, regardless of the hash function used to compute it.
+func (c PKCS11KMSClient) SignHash(digest common.Hash) ([]byte, error) {
+	return c.SignHashWithContext(c.ctx, digest)
+}
+
+// SignHashWithContext is the same as SignHash, but returns an error if the given context is done before the token
+// is reached. Note that an ongoing PKCS#11 call cannot be cancelled.
+func (c PKCS11KMSClient) SignHashWithContext(ctx context.Context, digest common.Hash) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sign digest: %w", err)
+	}
+
+	rawSig, err := c.session.sign(digest[:])
+	if err != nil {
+		return nil, wrapError("Sign", fmt.Errorf("failed to sign digest: %w", err))
+	}
+
+	return c.parseKMSSignature(digest, rawSig)
+}
+
+// SignTypedData asks the token to sign the EIP-712 digest of the given typed data.
+// If legacyV is set to true, the returned v will be either 27 or 28 (as returned by `eth_signTypedData_v4`).
+func (c PKCS11KMSClient) SignTypedData(typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return c.SignTypedDataWithContext(c.ctx, typedData, legacyV)
+}
+
+// SignTypedDataWithContext is the same as SignTypedData, but bound to the given context.
+func (c PKCS11KMSClient) SignTypedDataWithContext(ctx context.Context, typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return common2.SignTypedData(ctx, c.SignHashWithContext, typedData, legacyV)
+}
+
+// SignPersonalMessage asks the token to sign the given message prefixed with "\x19Ethereum Signed Message:\n" and
+// its length (EIP-191). The returned v is either 27 or 28 (as returned by `personal_sign`).
+func (c PKCS11KMSClient) SignPersonalMessage(msg []byte) ([]byte, error) {
+	return c.SignPersonalMessageWithContext(c.ctx, msg)
+}
+
+// SignPersonalMessageWithContext is the same as SignPersonalMessage, but bound to the given context.
+func (c PKCS11KMSClient) SignPersonalMessageWithContext(ctx context.Context, msg []byte) ([]byte, error) {
+	return common2.SignPersonalMessage(ctx, c.SignHashWithContext, msg)
+}
+
+// GetDefaultEVMTransactor returns the default KMS-backed instance of bind.TransactOpts.
+// Only `Context`, `From`, and `Signer` fields are set.
+func (c PKCS11KMSClient) GetDefaultEVMTransactor() *bind.TransactOpts {
+	return c.GetDefaultEVMTransactorWithContext(c.ctx)
+}
+
+// GetDefaultEVMTransactorWithContext is the same as GetDefaultEVMTransactor, but the given context is used for both
+// the transactor and its signer.
+func (c PKCS11KMSClient) GetDefaultEVMTransactorWithContext(ctx context.Context) *bind.TransactOpts {
+	return common2.NewTransactor(ctx, c.GetAddress(), c.GetEVMSignerFnWithContext(ctx))
+}
+
+// GetEVMSignerFn returns the EVM signer using the PKCS#11 token.
+func (c PKCS11KMSClient) GetEVMSignerFn() bind.SignerFn {
+	return c.GetEVMSignerFnWithContext(c.ctx)
+}
+
+// GetEVMSignerFnWithContext returns the EVM signer using the PKCS#11 token, bound to the given context.
+func (c PKCS11KMSClient) GetEVMSignerFnWithContext(ctx context.Context) bind.SignerFn {
+	return common2.NewSignerFn(ctx, c.GetAddress(), c.signer, c.SignHashWithContext)
+}
+
+// HasSignedTx checks if the given tx is signed by the current PKCS11KMSClient.
+func (c PKCS11KMSClient) HasSignedTx(tx *types.Transaction) (bool, error) {
+	return common2.HasSignedTx(c.signer, c.GetAddress(), tx)
+}
+
+// WithSigner assigns the given signer to the PKCS11KMSClient.
+func (c *PKCS11KMSClient) WithSigner(signer types.Signer) {
+	c.signer = signer
+}
+
+// WithChainID assigns given chainID (and updates the corresponding signer) to the PKCS11KMSClient.
+func (c *PKCS11KMSClient) WithChainID(chainID *big.Int) {
+	c.cfg.ChainID, c.signer = common2.SwitchChainID(c.cfg.ChainID, c.signer, chainID)
+}
+
+// sign signs the given digest with the CKM_ECDSA mechanism.
+func (s *session) sign(digest []byte) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.p11Ctx == nil {
+		return nil, fmt.Errorf("client closed")
+	}
+
+	err := s.p11Ctx.SignInit(s.handle, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, s.privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.p11Ctx.Sign(s.handle, digest)
+}
+
+// init opens a session to the configured token, logs in, and looks up the key pair.
+func (c *PKCS11KMSClient) init() error {
+	slotID, err := c.findSlot()
+	if err != nil {
+		return err
+	}
+
+	c.session.handle, err = c.session.p11Ctx.OpenSession(slotID, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return wrapError("OpenSession", fmt.Errorf("cannot open session on slot %v: %w", slotID, err))
+	}
+
+	// another client may already be logged in to the same token.
+	err = c.session.p11Ctx.Login(c.session.handle, pkcs11.CKU_USER, c.cfg.PIN)
+	if err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		return wrapError("Login", fmt.Errorf("cannot login to token: %w", err))
+	}
+
+	c.session.privateKey, err = c.findKey(pkcs11.CKO_PRIVATE_KEY)
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := c.findKey(pkcs11.CKO_PUBLIC_KEY)
+	if err != nil {
+		return err
+	}
+	c.publicKey, err = c.getPublicKey(publicKey)
+
+	return err
+}
+
+// findSlot returns the ID of the slot holding the configured token.
+func (c PKCS11KMSClient) findSlot() (uint, error) {
+	if c.cfg.TokenLabel == "" {
+		return *c.cfg.SlotID, nil
+	}
+
+	slots, err := c.session.p11Ctx.GetSlotList(true)
+	if err != nil {
+		return 0, wrapError("GetSlotList", fmt.Errorf("cannot list slots: %w", err))
+	}
+	for _, slot := range slots {
+		tokenInfo, err := c.session.p11Ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		if strings.TrimRight(tokenInfo.Label, " \x00") == c.cfg.TokenLabel {
+			return slot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: token %v not found", common2.ErrKeyNotFound, c.cfg.TokenLabel)
+}
+
+// findKey returns the handle of the unique EC key of the given class matching the configured label and ID.
+func (c PKCS11KMSClient) findKey(class uint) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+	}
+	if c.cfg.KeyLabel != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, c.cfg.KeyLabel))
+	}
+	if c.cfg.KeyID != "" {
+		keyID, _ := hex.DecodeString(c.cfg.KeyID)
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, keyID))
+	}
+
+	if err := c.session.p11Ctx.FindObjectsInit(c.session.handle, template); err != nil {
+		return 0, wrapError("FindObjects", fmt.Errorf("cannot find key: %w", err))
+	}
+	objects, _, err := c.session.p11Ctx.FindObjects(c.session.handle, 2)
+	if finalErr := c.session.p11Ctx.FindObjectsFinal(c.session.handle); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, wrapError("FindObjects", fmt.Errorf("cannot find key: %w", err))
+	}
+
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("%w: key (label=%v, id=%v)", common2.ErrKeyNotFound, c.cfg.KeyLabel, c.cfg.KeyID)
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, fmt.Errorf("%w: multiple keys match (label=%v, id=%v)", common2.ErrInvalidConfig, c.cfg.KeyLabel, c.cfg.KeyID)
+	}
+}
+
+// getPublicKey reads the CKA_EC_PARAMS and CKA_EC_POINT attributes of the given public key object.
+func (c PKCS11KMSClient) getPublicKey(publicKey pkcs11.ObjectHandle) (*ecdsa.PublicKey, error) {
+	attrs, err := c.session.p11Ctx.GetAttributeValue(c.session.handle, publicKey, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return nil, wrapError("GetAttributeValue", fmt.Errorf("cannot read public key: %w", err))
+	}
+
+	var ecParams, ecPoint []byte
+	for _, attr := range attrs {
+		switch attr.Type {
+		case pkcs11.CKA_EC_PARAMS:
+			ecParams = attr.Value
+		case pkcs11.CKA_EC_POINT:
+			ecPoint = attr.Value
+		}
+	}
+
+	return parseKMSPublicKey(ecParams, ecPoint)
+}
+
+// parseKMSSignature parses a signature returned by the CKM_ECDSA mechanism to a valid EVM-compatible signature.
+// The CKM_ECDSA mechanism returns the signature in the raw form r || s.
+// A valid EVM signature is a 65-byte long RLP-encoded of the form R || S || V (https://eips.ethereum.org/EIPS/eip-155).
+func (c PKCS11KMSClient) parseKMSSignature(digestedMsg common.Hash,
+	rawSig []byte,
+) ([]byte, error) {
+	// recover r, s
+	sig, err := common2.ParseRawSignature(rawSig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal kms signature: %v", err)
+	}
+
+	// convert the signature into a valid EVM signature.
+	return common2.KmsToEVMSignature(*c.publicKey, sig, digestedMsg)
+}
+
+// parseKMSPublicKey parses the CKA_EC_PARAMS and CKA_EC_POINT attributes of a PKCS#11 public key to a valid
+// ecdsa.PublicKey.
+func parseKMSPublicKey(ecParams, ecPoint []byte) (*ecdsa.PublicKey, error) {
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(ecParams, &curve); err != nil {
+		return nil, fmt.Errorf("cannot decode CKA_EC_PARAMS %x: %v", ecParams, err)
+	}
+	if !curve.Equal(common2.OIDNamedCurveSecp256k1) {
+		return nil, fmt.Errorf("%w: unsupported curve %v, expected secp256k1", common2.ErrWrongKeySpec, curve)
+	}
+
+	// CKA_EC_POINT is a DER-encoded OCTET STRING holding the uncompressed point, although some tokens return the raw
+	// point.
+	var point []byte
+	if rest, err := asn1.Unmarshal(ecPoint, &point); err != nil || len(rest) != 0 {
+		point = ecPoint
+	}
+
+	return crypto.UnmarshalPubkey(point)
+}
@@ -0,0 +1,304 @@
+package pkcs11kms
+
+import (
+	"context"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miekg/pkcs11"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testTokenLabel = "evm-kms-test"
+	testSOPIN      = "5678"
+	testPIN        = "1234"
+	testKeyLabel   = "evm-ecdsa"
+)
+
+var (
+	receiverAddr = common.HexToAddress("0x243e9517a24813a2d73e9a74cd2c1c699d0ff7a5")
+
+	// softHSMPaths are the usual install locations of the SoftHSMv2 library.
+	softHSMPaths = []string{
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+		"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+	}
+)
+
+// setupSoftHSM initializes a fresh SoftHSMv2 token holding a secp256k1 key pair, and returns the corresponding Config.
+// The test is skipped if SoftHSMv2 is not installed; its location can be given via the `SOFTHSM2_LIB` environment
+// variable.
+func setupSoftHSM(t *testing.T) Config {
+	modulePath := os.Getenv("SOFTHSM2_LIB")
+	for _, path := range softHSMPaths {
+		if modulePath != "" {
+			break
+		}
+		if _, err := os.Stat(path); err == nil {
+			modulePath = path
+		}
+	}
+	if modulePath == "" {
+		t.Skip("SoftHSMv2 not found, set SOFTHSM2_LIB to run this test")
+	}
+
+	dir, err := ioutil.TempDir("", "softhsm")
+	if err != nil {
+		panic(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	confPath := filepath.Join(dir, "softhsm2.conf")
+	conf := fmt.Sprintf("directories.tokendir = %v\nobjectstore.backend = file\n", dir)
+	if err = ioutil.WriteFile(confPath, []byte(conf), 0600); err != nil {
+		panic(err)
+	}
+	os.Setenv("SOFTHSM2_CONF", confPath)
+	t.Cleanup(func() { _ = os.Unsetenv("SOFTHSM2_CONF") })
+
+	p := pkcs11.New(modulePath)
+	if p == nil {
+		panic(fmt.Sprintf("cannot load %v", modulePath))
+	}
+	if err = p.Initialize(); err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = p.Finalize()
+		p.Destroy()
+	}()
+
+	slots, err := p.GetSlotList(false)
+	if err != nil {
+		panic(err)
+	}
+	if err = p.InitToken(slots[0], testSOPIN, testTokenLabel); err != nil {
+		panic(err)
+	}
+
+	// the token is re-assigned to a new slot after initialization.
+	slots, err = p.GetSlotList(true)
+	if err != nil {
+		panic(err)
+	}
+	session, err := p.OpenSession(slots[0], pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		panic(err)
+	}
+	defer p.CloseSession(session)
+
+	if err = p.Login(session, pkcs11.CKU_SO, testSOPIN); err != nil {
+		panic(err)
+	}
+	if err = p.InitPIN(session, testPIN); err != nil {
+		panic(err)
+	}
+	_ = p.Logout(session)
+	if err = p.Login(session, pkcs11.CKU_USER, testPIN); err != nil {
+		panic(err)
+	}
+	defer p.Logout(session)
+
+	ecParams, err := asn1.Marshal(common2.OIDNamedCurveSecp256k1)
+	if err != nil {
+		panic(err)
+	}
+	_, _, err = p.GenerateKeyPair(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, testKeyLabel),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, []byte{1}),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, ecParams),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, testKeyLabel),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, []byte{1}),
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return Config{
+		ModulePath: modulePath,
+		TokenLabel: testTokenLabel,
+		PIN:        testPIN,
+		KeyLabel:   testKeyLabel,
+		ChainID:    80001,
+	}
+}
+
+func TestPKCS11KMSClient_Sign(t *testing.T) {
+	cfg := setupSoftHSM(t)
+
+	c, err := NewPKCS11KMSClient(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		digest := crypto.Keccak256Hash([]byte(fmt.Sprintf("Hello World %v", i)))
+		sig, err := c.SignHash(digest)
+		if err != nil {
+			panic(err)
+		}
+		pubKey, err := crypto.SigToPub(digest[:], sig)
+		if err != nil {
+			panic(err)
+		}
+		if crypto.PubkeyToAddress(*pubKey) != c.GetAddress() {
+			panic("invalid signature")
+		}
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(80001),
+		To:        &receiverAddr,
+		GasTipCap: big.NewInt(1000000),
+		GasFeeCap: big.NewInt(2000000),
+		Gas:       50000,
+		Value:     big.NewInt(100),
+	})
+	signedTx, err := c.GetDefaultEVMTransactor().Signer(c.GetAddress(), tx)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = c.HasSignedTx(signedTx); err != nil {
+		panic(err)
+	}
+
+	if _, err = c.GetEVMSignerFn()(receiverAddr, tx); err != bind.ErrNotAuthorized {
+		panic(fmt.Sprintf("expected %v, got %v", bind.ErrNotAuthorized, err))
+	}
+
+	// wrong key label
+	cfg.KeyLabel = "unknown"
+	if _, err = NewPKCS11KMSClient(context.Background(), cfg); !errors.Is(err, common2.ErrKeyNotFound) {
+		panic(fmt.Sprintf("expected ErrKeyNotFound with an unknown key label, got %v", err))
+	}
+}
+
+func TestPKCS11KMSClient_SharedModule(t *testing.T) {
+	cfg := setupSoftHSM(t)
+
+	c1, err := NewPKCS11KMSClient(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+	c2, err := NewPKCS11KMSClient(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+	defer c2.Close()
+
+	// closing a client must not break the other clients of the same module
+	if err = c1.Close(); err != nil {
+		panic(err)
+	}
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+	if _, err = c2.SignHash(digest); err != nil {
+		panic(err)
+	}
+	if _, err = c1.SignHash(digest); err == nil {
+		panic("expected an error with a closed client")
+	}
+
+	// the module is loaded again after its last client is closed
+	if err = c2.Close(); err != nil {
+		panic(err)
+	}
+	c3, err := NewPKCS11KMSClient(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+	defer c3.Close()
+	if _, err = c3.SignHash(digest); err != nil {
+		panic(err)
+	}
+}
+
+func TestParseKMSPublicKey(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	point := crypto.FromECDSAPub(&privateKey.PublicKey)
+
+	ecParams, err := asn1.Marshal(common2.OIDNamedCurveSecp256k1)
+	if err != nil {
+		panic(err)
+	}
+	ecPoint, err := asn1.Marshal(point)
+	if err != nil {
+		panic(err)
+	}
+
+	// DER-encoded and raw points
+	for _, p := range [][]byte{ecPoint, point} {
+		pubKey, err := parseKMSPublicKey(ecParams, p)
+		if err != nil {
+			panic(err)
+		}
+		if crypto.PubkeyToAddress(*pubKey) != crypto.PubkeyToAddress(privateKey.PublicKey) {
+			panic("public key mismatch")
+		}
+	}
+
+	// P-256
+	p256Params, err := asn1.Marshal(asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7})
+	if err != nil {
+		panic(err)
+	}
+	if _, err = parseKMSPublicKey(p256Params, ecPoint); !errors.Is(err, common2.ErrWrongKeySpec) {
+		panic(fmt.Sprintf("expected ErrWrongKeySpec for a P-256 key, got %v", err))
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	testCases := []struct {
+		err  error
+		kind error
+	}{
+		{pkcs11.Error(pkcs11.CKR_PIN_INCORRECT), common2.ErrPermissionDenied},
+		{pkcs11.Error(pkcs11.CKR_USER_NOT_LOGGED_IN), common2.ErrPermissionDenied},
+		{pkcs11.Error(pkcs11.CKR_KEY_HANDLE_INVALID), common2.ErrKeyNotFound},
+		{pkcs11.Error(pkcs11.CKR_KEY_TYPE_INCONSISTENT), common2.ErrWrongKeySpec},
+		{pkcs11.Error(pkcs11.CKR_DEVICE_ERROR), nil},
+		{fmt.Errorf("cannot login to token: %w", pkcs11.Error(pkcs11.CKR_PIN_INCORRECT)), common2.ErrPermissionDenied},
+		{fmt.Errorf("not a PKCS#11 error"), nil},
+	}
+
+	for _, tc := range testCases {
+		if kind := errorKind(tc.err); kind != tc.kind {
+			panic(fmt.Sprintf("%v: expected kind %v, got %v", tc.err, tc.kind, kind))
+		}
+	}
+
+	err := wrapError("Login", pkcs11.Error(pkcs11.CKR_PIN_INCORRECT))
+	if !errors.Is(err, common2.ErrPermissionDenied) {
+		panic(fmt.Sprintf("expected ErrPermissionDenied, got %v", err))
+	}
+	var p11Err pkcs11.Error
+	if !errors.As(err, &p11Err) || p11Err != pkcs11.CKR_PIN_INCORRECT {
+		panic(fmt.Sprintf("expected CKR_PIN_INCORRECT, got %v", err))
+	}
+}
@@ -0,0 +1,152 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"sync"
+)
+
+// SignerPool holds many KMSSigners (possibly of different backends), indexed by their EVM addresses. It exposes a
+// single bind.SignerFn which routes each transaction to the KMSSigner of its `from` address.
+//
+// A SignerPool is safe for concurrent use.
+type SignerPool struct {
+	mtx       sync.RWMutex
+	signers   map[common.Address]KMSSigner
+	addresses []common.Address
+}
+
+// NewSignerPool creates a new SignerPool with the given KMSSigners. An error is returned if two KMSSigners share the
+// same address.
+func NewSignerPool(signers ...KMSSigner) (*SignerPool, error) {
+	p := &SignerPool{signers: make(map[common.Address]KMSSigner)}
+	for _, signer := range signers {
+		if err := p.Add(signer); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Add adds the given KMSSigner to the SignerPool. An error is returned if the SignerPool already holds a KMSSigner
+// with the same address.
+func (p *SignerPool) Add(signer KMSSigner) error {
+	address := signer.GetAddress()
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if _, ok := p.signers[address]; ok {
+		return fmt.Errorf("%w: duplicate signer for address %v", ErrInvalidConfig, address)
+	}
+	p.signers[address] = signer
+	p.addresses = append(p.addresses, address)
+
+	return nil
+}
+
+// Remove removes the KMSSigner of the given address from the SignerPool. It returns false if the SignerPool does not
+// hold such KMSSigner.
+func (p *SignerPool) Remove(address common.Address) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if _, ok := p.signers[address]; !ok {
+		return false
+	}
+	delete(p.signers, address)
+	for i, addr := range p.addresses {
+		if addr == address {
+			p.addresses = append(p.addresses[:i], p.addresses[i+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+// Get returns the KMSSigner of the given address.
+func (p *SignerPool) Get(address common.Address) (KMSSigner, bool) {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	signer, ok := p.signers[address]
+
+	return signer, ok
+}
+
+// Addresses returns the addresses of the SignerPool, in the order their KMSSigners were added.
+func (p *SignerPool) Addresses() []common.Address {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	return append([]common.Address{}, p.addresses...)
+}
+
+// Len returns the number of KMSSigners of the SignerPool.
+func (p *SignerPool) Len() int {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	return len(p.signers)
+}
+
+// GetEVMSignerFn returns a bind.SignerFn which signs a transaction with the KMSSigner of the given `from` address.
+// It returns bind.ErrNotAuthorized if the SignerPool holds no KMSSigner for this address.
+func (p *SignerPool) GetEVMSignerFn() bind.SignerFn {
+	return func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		signer, ok := p.Get(addr)
+		if !ok {
+			return nil, bind.ErrNotAuthorized
+		}
+
+		return signer.GetEVMSignerFn()(addr, tx)
+	}
+}
+
+// GetEVMSignerFnWithContext is the same as GetEVMSignerFn, but the remote calls are bound to the given context.
+func (p *SignerPool) GetEVMSignerFnWithContext(ctx context.Context) bind.SignerFn {
+	return func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		signer, ok := p.Get(addr)
+		if !ok {
+			return nil, bind.ErrNotAuthorized
+		}
+
+		return signer.GetEVMSignerFnWithContext(ctx)(addr, tx)
+	}
+}
+
+// GetEVMTransactor returns a bind.TransactOpts sending from the given address, whose signer is routed by the
+// SignerPool. Only `Context`, `From`, and `Signer` fields are set, `Context` being context.Background().
+func (p *SignerPool) GetEVMTransactor(from common.Address) (*bind.TransactOpts, error) {
+	return p.GetEVMTransactorWithContext(context.Background(), from)
+}
+
+// GetEVMTransactorWithContext is the same as GetEVMTransactor, but the given context is used for both the transactor
+// and the KMS calls made by its signer.
+func (p *SignerPool) GetEVMTransactorWithContext(ctx context.Context, from common.Address) (*bind.TransactOpts, error) {
+	if _, ok := p.Get(from); !ok {
+		return nil, fmt.Errorf("%w: no signer for address %v", ErrKeyNotFound, from)
+	}
+
+	return &bind.TransactOpts{
+		Context: ctx,
+		From:    from,
+		Signer:  p.GetEVMSignerFnWithContext(ctx),
+	}, nil
+}
+
+// WithChainID assigns the given chainID to all the KMSSigners of the SignerPool.
+func (p *SignerPool) WithChainID(chainID *big.Int) {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	for _, signer := range p.signers {
+		signer.WithChainID(chainID)
+	}
+}
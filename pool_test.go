@@ -0,0 +1,117 @@
+package kms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/LampardNguyen234/evm-kms/localkms"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+)
+
+func newLocalSigner() KMSSigner {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+
+	return localkms.NewLocalKMSClientFromPrivateKey(context.Background(), privateKey, 80001)
+}
+
+func TestSignerPool_GetEVMSignerFn(t *testing.T) {
+	signers := []KMSSigner{newLocalSigner(), newLocalSigner(), newLocalSigner()}
+	pool, err := NewSignerPool(signers...)
+	if err != nil {
+		panic(err)
+	}
+	if pool.Len() != len(signers) {
+		panic(fmt.Sprintf("expected %v signers, got %v", len(signers), pool.Len()))
+	}
+
+	txSigner := types.NewLondonSigner(big.NewInt(80001))
+	for i, signer := range signers {
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(80001),
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(1e9),
+			GasFeeCap: big.NewInt(1e10),
+			Gas:       21000,
+			To:        &common.Address{},
+			Value:     big.NewInt(1),
+		})
+
+		transactor, err := pool.GetEVMTransactor(signer.GetAddress())
+		if err != nil {
+			panic(err)
+		}
+		signedTx, err := transactor.Signer(transactor.From, tx)
+		if err != nil {
+			panic(err)
+		}
+		from, err := types.Sender(txSigner, signedTx)
+		if err != nil {
+			panic(err)
+		}
+		if from != signer.GetAddress() {
+			panic(fmt.Sprintf("expected sender %v, got %v", signer.GetAddress(), from))
+		}
+	}
+
+	unknownAddress := newLocalSigner().GetAddress()
+	_, err = pool.GetEVMSignerFn()(unknownAddress, types.NewTx(&types.LegacyTx{}))
+	if !errors.Is(err, bind.ErrNotAuthorized) {
+		panic(fmt.Sprintf("expected ErrNotAuthorized, got %v", err))
+	}
+	_, err = pool.GetEVMTransactor(unknownAddress)
+	if !errors.Is(err, ErrKeyNotFound) {
+		panic(fmt.Sprintf("expected ErrKeyNotFound, got %v", err))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	transactor, err := pool.GetEVMTransactorWithContext(ctx, signers[0].GetAddress())
+	if err != nil {
+		panic(err)
+	}
+	if transactor.Context != ctx {
+		panic("expected the transactor to use the given context")
+	}
+}
+
+func TestSignerPool_AddRemove(t *testing.T) {
+	signer := newLocalSigner()
+	pool, err := NewSignerPool(signer)
+	if err != nil {
+		panic(err)
+	}
+
+	if err = pool.Add(signer); !errors.Is(err, ErrInvalidConfig) {
+		panic(fmt.Sprintf("expected ErrInvalidConfig, got %v", err))
+	}
+
+	other := newLocalSigner()
+	if err = pool.Add(other); err != nil {
+		panic(err)
+	}
+	addresses := pool.Addresses()
+	if len(addresses) != 2 || addresses[0] != signer.GetAddress() || addresses[1] != other.GetAddress() {
+		panic(fmt.Sprintf("invalid addresses %v", addresses))
+	}
+
+	if !pool.Remove(signer.GetAddress()) {
+		panic("expected the signer to be removed")
+	}
+	if pool.Remove(signer.GetAddress()) {
+		panic("expected the signer to be already removed")
+	}
+	if _, ok := pool.Get(signer.GetAddress()); ok {
+		panic("expected no signer for the removed address")
+	}
+	if pool.Len() != 1 {
+		panic(fmt.Sprintf("expected 1 signer, got %v", pool.Len()))
+	}
+}
@@ -0,0 +1,225 @@
+package kms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/LampardNguyen234/evm-kms/awskms"
+	"github.com/LampardNguyen234/evm-kms/azurekms"
+	"github.com/LampardNguyen234/evm-kms/gcpkms"
+	"github.com/LampardNguyen234/evm-kms/keystorekms"
+	"github.com/LampardNguyen234/evm-kms/localkms"
+	"github.com/LampardNguyen234/evm-kms/vaultkms"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BackendFactory creates a KMSSigner from the raw JSON config section of a backend.
+//
+// A backend is responsible for decoding and validating its own config section.
+type BackendFactory func(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error)
+
+// backend is a KMS backend registered via RegisterBackend or RegisterBackendWithConfig.
+type backend struct {
+	factory BackendFactory
+
+	// config is the type of the config section of the backend, or nil if it is unknown.
+	config reflect.Type
+}
+
+var (
+	backendsMtx sync.RWMutex
+	backends    = make(map[string]backend)
+)
+
+func init() {
+	RegisterBackendWithConfig(awsType, awskms.CredentialsConfig{}, newAWSBackend)
+	RegisterBackendWithConfig(gcpType, gcpkms.Config{}, newGCPBackend)
+	RegisterBackendWithConfig(localType, localkms.Config{}, newLocalBackend)
+	RegisterBackendWithConfig(keystoreType, keystorekms.Config{}, newKeystoreBackend)
+	RegisterBackendWithConfig(vaultType, vaultkms.Config{}, newVaultBackend)
+	RegisterBackendWithConfig(azureType, azurekms.Config{}, newAzureBackend)
+}
+
+// RegisterBackend makes a KMS backend available under the given name, so that it can be selected by the `type` field
+// of a Config. The config section of the backend is the top-level field with the same name.
+//
+// Example:
+//
+//	func init() {
+//		kms.RegisterBackend("my-kms", func(ctx context.Context, rawConfig json.RawMessage) (kms.KMSSigner, error) {
+//			var cfg MyConfig
+//			if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+//				return nil, err
+//			}
+//			return NewMyKMSClient(ctx, cfg)
+//		})
+//	}
+//
+// Names are case-insensitive. RegisterBackend panics if the factory is nil or if a backend with the same name has
+// already been registered.
+func RegisterBackend(name string, factory BackendFactory) {
+	registerBackend(name, backend{factory: factory})
+}
+
+// RegisterBackendWithConfig is the same as RegisterBackend, but also declares the type of the config section of the
+// backend via a zero value of it (e.g, `MyConfig{}`). If the config type has an `IsValid() (bool, error)` method, it
+// is used by Config.IsValid to check the config section before creating any KMSSigner. The config files loaded via
+// LoadConfigFromFile are also checked for fields unknown to the config type.
+func RegisterBackendWithConfig(name string, config interface{}, factory BackendFactory) {
+	if config == nil {
+		panic(fmt.Sprintf("kms: RegisterBackendWithConfig config for `%v` is nil", name))
+	}
+
+	registerBackend(name, backend{factory: factory, config: reflect.TypeOf(config)})
+}
+
+func registerBackend(name string, b backend) {
+	name = strings.ToLower(name)
+	if name == "" {
+		panic("kms: RegisterBackend with an empty name")
+	}
+	if b.factory == nil {
+		panic(fmt.Sprintf("kms: RegisterBackend factory for `%v` is nil", name))
+	}
+
+	backendsMtx.Lock()
+	defer backendsMtx.Unlock()
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("kms: RegisterBackend called twice for backend `%v`", name))
+	}
+	backends[name] = b
+}
+
+// RegisteredBackends returns the sorted list of names of the registered backends.
+func RegisteredBackends() []string {
+	backendsMtx.RLock()
+	defer backendsMtx.RUnlock()
+
+	res := make([]string, 0, len(backends))
+	for name := range backends {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+func getBackend(name string) (backend, error) {
+	backendsMtx.RLock()
+	b, ok := backends[strings.ToLower(name)]
+	backendsMtx.RUnlock()
+	if !ok {
+		return backend{}, fmt.Errorf("KMS Config type `%v` not supported, registered backends: [%v]",
+			strings.ToLower(name), strings.Join(RegisteredBackends(), ", "))
+	}
+
+	return b, nil
+}
+
+// validateConfig decodes the given config section into the config type of the backend, if known, and checks it via
+// its IsValid method, if any.
+func (b backend) validateConfig(rawConfig json.RawMessage) error {
+	if b.config == nil {
+		return nil
+	}
+
+	cfg := reflect.New(b.config)
+	if err := json.Unmarshal(rawConfig, cfg.Interface()); err != nil {
+		return err
+	}
+
+	if validator, ok := cfg.Elem().Interface().(interface{ IsValid() (bool, error) }); ok {
+		if _, err := validator.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newAWSBackend(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error) {
+	var cfg awskms.CredentialsConfig
+	if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+		return nil, err
+	}
+
+	c, err := awskms.NewAmazonKMSClientWithCredentials(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newGCPBackend(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error) {
+	var cfg gcpkms.Config
+	if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+		return nil, err
+	}
+
+	c, err := gcpkms.NewGoogleKMSClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newLocalBackend(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error) {
+	var cfg localkms.Config
+	if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+		return nil, err
+	}
+
+	c, err := localkms.NewLocalKMSClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newKeystoreBackend(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error) {
+	var cfg keystorekms.Config
+	if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+		return nil, err
+	}
+
+	c, err := keystorekms.NewKeystoreKMSClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newVaultBackend(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error) {
+	var cfg vaultkms.Config
+	if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+		return nil, err
+	}
+
+	c, err := vaultkms.NewVaultKMSClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newAzureBackend(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error) {
+	var cfg azurekms.Config
+	if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+		return nil, err
+	}
+
+	c, err := azurekms.NewAzureKMSClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
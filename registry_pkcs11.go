@@ -0,0 +1,29 @@
+//go:build cgo
+// +build cgo
+
+package kms
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/LampardNguyen234/evm-kms/pkcs11kms"
+)
+
+// the pkcs11 backend requires cgo, so it is only registered when cgo is enabled.
+func init() {
+	RegisterBackendWithConfig(pkcs11Type, pkcs11kms.Config{}, newPKCS11Backend)
+}
+
+func newPKCS11Backend(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error) {
+	var cfg pkcs11kms.Config
+	if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+		return nil, err
+	}
+
+	c, err := pkcs11kms.NewPKCS11KMSClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,31 @@
+//go:build cgo
+// +build cgo
+
+package kms
+
+import (
+	"testing"
+)
+
+func TestLoadConfig_PKCS11(t *testing.T) {
+	found := false
+	for _, name := range RegisteredBackends() {
+		found = found || name == pkcs11Type
+	}
+	if !found {
+		panic("pkcs11 backend not registered")
+	}
+
+	// the config section is validated by the registered config type
+	_, err := LoadConfig(map[string]interface{}{
+		"type": "pkcs11",
+		"pkcs11": map[string]interface{}{
+			"TokenLabel": "evm-kms",
+			"PIN":        "1234",
+			"KeyLabel":   "evm-ecdsa",
+		},
+	})
+	if err == nil {
+		panic("expected an error with an empty ModulePath")
+	}
+}
@@ -0,0 +1,121 @@
+package kms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// unregisterBackend removes the backend with the given name from the registry, so that tests can register it again.
+func unregisterBackend(name string) {
+	backendsMtx.Lock()
+	defer backendsMtx.Unlock()
+
+	delete(backends, strings.ToLower(name))
+}
+
+func TestRegisterBackend(t *testing.T) {
+	type testConfig struct {
+		KeyID string `json:"KeyID"`
+	}
+
+	errTest := fmt.Errorf("test backend")
+	var received testConfig
+	t.Cleanup(func() { unregisterBackend("test-kms") })
+	RegisterBackend("Test-KMS", func(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error) {
+		if err := json.Unmarshal(rawConfig, &received); err != nil {
+			return nil, err
+		}
+		return nil, errTest
+	})
+
+	cfg, err := LoadConfig(map[string]interface{}{
+		"type": "test-kms",
+		"test-kms": map[string]interface{}{
+			"KeyID": "KEY_ID",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = NewKMSSignerFromConfig(*cfg)
+	if err != errTest {
+		panic(fmt.Sprintf("expected error %v, got %v", errTest, err))
+	}
+	if received.KeyID != "KEY_ID" {
+		panic(fmt.Sprintf("expected KeyID %v, got %v", "KEY_ID", received.KeyID))
+	}
+
+	// missing config section
+	_, err = LoadConfig(map[string]interface{}{"type": "test-kms"})
+	if err == nil {
+		panic("expected an error for a missing config section")
+	}
+}
+
+// validatedConfig is the config section of the `validated-kms` test backend.
+type validatedConfig struct {
+	KeyID string `json:"KeyID"`
+}
+
+func (cfg validatedConfig) IsValid() (bool, error) {
+	if cfg.KeyID == "" {
+		return false, fmt.Errorf("empty KeyID")
+	}
+
+	return true, nil
+}
+
+func TestRegisterBackendWithConfig(t *testing.T) {
+	t.Cleanup(func() { unregisterBackend("validated-kms") })
+	RegisterBackendWithConfig("validated-kms", validatedConfig{},
+		func(ctx context.Context, rawConfig json.RawMessage) (KMSSigner, error) {
+			return nil, fmt.Errorf("not implemented")
+		})
+
+	_, err := LoadConfig(map[string]interface{}{
+		"type":          "validated-kms",
+		"validated-kms": map[string]interface{}{"KeyID": "KEY_ID"},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// the config section is checked before creating any signer
+	_, err = LoadConfig(map[string]interface{}{
+		"type":          "validated-kms",
+		"validated-kms": map[string]interface{}{},
+	})
+	if err == nil || !strings.Contains(err.Error(), "empty KeyID") {
+		panic(fmt.Sprintf("expected an empty KeyID error, got %v", err))
+	}
+
+	// unknown fields of the config section are rejected when loading from a file
+	filePath := filepath.Join(t.TempDir(), "typo.json")
+	err = ioutil.WriteFile(filePath, []byte(`{"type": "validated-kms", "validated-kms": {"KeyId": "KEY_ID"}}`), 0600)
+	if err != nil {
+		panic(err)
+	}
+	_, err = LoadConfigFromFile(filePath)
+	if err == nil || !strings.Contains(err.Error(), "unknown field `validated-kms.KeyId`, did you mean `KeyID`?") {
+		panic(fmt.Sprintf("expected an unknown field error, got %v", err))
+	}
+}
+
+func TestNewKMSSignerFromConfig_UnknownType(t *testing.T) {
+	_, err := NewKMSSignerFromConfig(Config{Type: "unknown"})
+	if err == nil {
+		panic("expected an error for an unknown backend")
+	}
+
+	for _, name := range RegisteredBackends() {
+		if !strings.Contains(err.Error(), name) {
+			panic(fmt.Sprintf("expected backend `%v` to be listed in error: %v", name, err))
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package vaultkms
+
+import (
+	"encoding/json"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"os"
+)
+
+const (
+	defaultMountPath        = "transit"
+	defaultAppRoleMountPath = "approle"
+)
+
+// AppRoleConfig consists of required information to authenticate against Vault using the AppRole auth method.
+type AppRoleConfig struct {
+	// RoleID is the RoleID of the AppRole.
+	RoleID string `json:"RoleID"`
+
+	// SecretID is the SecretID of the AppRole.
+	SecretID string `json:"SecretID"`
+
+	// MountPath is the path where the AppRole auth method is mounted. Default: "approle".
+	MountPath string `json:"MountPath,omitempty"`
+}
+
+func (cfg AppRoleConfig) isValid() bool {
+	return cfg.RoleID != "" && cfg.SecretID != ""
+}
+
+// Config represents required information to create a Vault Transit client.
+//
+// Either Token or AppRole must be provided.
+type Config struct {
+	// Address is the address of the Vault server.
+	//
+	// Example: "https://vault.example.com:8200".
+	// Leave this field empty if the environment variable `VAULT_ADDR` has been set.
+	Address string `json:"Address"`
+
+	// Namespace is the Vault Enterprise namespace, if any.
+	Namespace string `json:"Namespace,omitempty"`
+
+	// MountPath is the path where the Transit secrets engine is mounted. Default: "transit".
+	MountPath string `json:"MountPath,omitempty"`
+
+	// KeyName is the name of the Transit key.
+	KeyName string `json:"KeyName"`
+
+	// KeyVersion is the version of the Transit key used for signing. If empty, the latest version is used.
+	KeyVersion int `json:"KeyVersion,omitempty"`
+
+	// Token is the Vault token used to authenticate requests.
+	//
+	// Leave this field empty if the environment variable `VAULT_TOKEN` has been set, or if AppRole is used.
+	Token string `json:"Token,omitempty"`
+
+	// AppRole is the AppRole credentials used to retrieve a Vault token.
+	AppRole *AppRoleConfig `json:"AppRole,omitempty"`
+
+	// ChainID is the ID of the target EVM chain.
+	//
+	// See https://chainlist.org.
+	ChainID uint64 `json:"ChainID"`
+}
+
+// IsValid checks if a Config is valid. An empty Address or Token is valid if the corresponding environment variable
+// (`VAULT_ADDR` or `VAULT_TOKEN`) is set.
+func (cfg Config) IsValid() (bool, error) {
+	cfg = cfg.withEnvDefaults()
+
+	if cfg.Address == "" {
+		return false, fmt.Errorf("empty Address")
+	}
+
+	if cfg.KeyName == "" {
+		return false, fmt.Errorf("empty KeyName")
+	}
+
+	if cfg.KeyVersion < 0 {
+		return false, fmt.Errorf("invalid KeyVersion %v", cfg.KeyVersion)
+	}
+
+	if cfg.AppRole != nil {
+		if cfg.Token != "" {
+			return false, fmt.Errorf("only one of Token and AppRole must be set")
+		}
+		if !cfg.AppRole.isValid() {
+			return false, fmt.Errorf("invalid AppRole")
+		}
+	} else if cfg.Token == "" {
+		return false, fmt.Errorf("empty Token and AppRole")
+	}
+
+	return true, nil
+}
+
+// LoadConfigFromFile loads the config from the given config file.
+func LoadConfigFromFile(filePath string) (*Config, error) {
+	f, err := common2.ReadConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	err = json.Unmarshal(f, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg = cfg.withEnvDefaults()
+	if _, err = cfg.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// withEnvDefaults returns a copy of the Config whose empty Address and Token are taken from the `VAULT_ADDR` and
+// `VAULT_TOKEN` environment variables. The token is not taken from the environment if AppRole is used.
+func (cfg Config) withEnvDefaults() Config {
+	if cfg.Address == "" {
+		cfg.Address = os.Getenv("VAULT_ADDR")
+	}
+	if cfg.Token == "" && cfg.AppRole == nil {
+		cfg.Token = os.Getenv("VAULT_TOKEN")
+	}
+
+	return cfg
+}
+
+func (cfg Config) mountPath() string {
+	if cfg.MountPath == "" {
+		return defaultMountPath
+	}
+	return cfg.MountPath
+}
+
+func (cfg AppRoleConfig) mountPath() string {
+	if cfg.MountPath == "" {
+		return defaultAppRoleMountPath
+	}
+	return cfg.MountPath
+}
@@ -0,0 +1,28 @@
+package vaultkms_test
+
+import (
+	"context"
+	kms "github.com/LampardNguyen234/evm-kms"
+	"github.com/LampardNguyen234/evm-kms/kmstest"
+	"github.com/LampardNguyen234/evm-kms/vaultkms"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	kmstest.RunConformance(t, func(t *testing.T) kms.KMSSigner {
+		server := vaultkms.NewTestServer(1)
+		t.Cleanup(server.Close)
+
+		c, err := vaultkms.NewVaultKMSClient(context.Background(), vaultkms.Config{
+			Address: server.URL,
+			Token:   vaultkms.TestToken,
+			KeyName: vaultkms.TestKeyName,
+			ChainID: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return c
+	})
+}
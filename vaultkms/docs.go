@@ -0,0 +1,6 @@
+// Package vaultkms uses the HashiCorp Vault's Transit secrets engine to provide a signing interface for EVM-compatible
+// transactions.
+//
+// Rather than directly accessing a private key to sign a transaction, the client makes calls to the remote
+// Vault server to do so and the private key never leaves Vault. The Transit key must be of type `ecdsa-p256k1`.
+package vaultkms
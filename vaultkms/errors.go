@@ -0,0 +1,56 @@
+package vaultkms
+
+import (
+	"errors"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"net/http"
+	"strings"
+)
+
+// apiError is an error response of the Vault HTTP API.
+type apiError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Errors are the error messages returned by Vault, if any.
+	Errors []string
+}
+
+// Error implements the error interface.
+func (e *apiError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("vault returned status %v", e.StatusCode)
+	}
+
+	return fmt.Sprintf("vault returned status %v: %v", e.StatusCode, strings.Join(e.Errors, "; "))
+}
+
+// wrapError wraps an error returned by the Vault HTTP API into a common2.KMSError, whose Kind is derived from the
+// HTTP status code.
+func wrapError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return common2.NewKMSError(op, errorKind(err), err)
+}
+
+// errorKind maps an error returned by the Vault HTTP API to one of the sentinel errors of the common package.
+func errorKind(err error) error {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return common2.ErrPermissionDenied
+	case http.StatusNotFound:
+		return common2.ErrKeyNotFound
+	case http.StatusTooManyRequests:
+		return common2.ErrThrottled
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+package vaultkms
+
+// Exported for the external tests of the package.
+var NewTestServer = newTransitServer
+
+const (
+	TestToken   = testToken
+	TestKeyName = testKeyName
+)
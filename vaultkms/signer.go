@@ -0,0 +1,425 @@
+package vaultkms
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	keyType          = "ecdsa-p256k1"
+	hashAlgorithm    = "sha2-256"
+	marshalAlgorithm = "asn1"
+	signaturePrefix  = "vault:v"
+
+	// tokenRenewalRatio is the fraction of the TTL of an AppRole token after which a new token is retrieved.
+	tokenRenewalRatio = 0.8
+)
+
+// appRoleToken is a Vault token retrieved via AppRole, shared by all the copies of a VaultKMSClient.
+type appRoleToken struct {
+	mtx   sync.Mutex
+	token string
+
+	// renewAt is the time after which a new token is retrieved, or zero if the token does not expire.
+	renewAt time.Time
+}
+
+// VaultKMSClient implements basic functionalities of a HashiCorp Vault Transit client for signing transactions.
+type VaultKMSClient struct {
+	httpClient *http.Client
+	ctx        context.Context
+	cfg        Config
+	token      string
+	appRole    *appRoleToken
+	keyVersion int
+	publicKey  *ecdsa.PublicKey
+	signer     types.Signer
+}
+
+// NewVaultKMSClient creates a new Vault Transit client with the given config. An empty cfg.Address or cfg.Token is
+// taken from the `VAULT_ADDR` or `VAULT_TOKEN` environment variable.
+//
+// If txSigner is not provided, the signer will be initiated as a types.NewLondonSigner(cfg.ChainID).
+// Note that only the first value of txSigner is used.
+//
+// Example:
+//
+//	cfg := Config{
+//		Address: "https://vault.example.com:8200",
+//		KeyName: "evm-ecdsa",
+//		Token:   "VAULT_TOKEN",
+//		ChainID: 1,
+//	}
+//
+//	c, err := NewVaultKMSClient(context.Background(), cfg)
+//	if err != nil {
+//		panic(err)
+//	}
+func NewVaultKMSClient(ctx context.Context, cfg Config, txSigner ...types.Signer) (*VaultKMSClient, error) {
+	return NewVaultKMSClientWithHTTPClient(ctx, cfg, http.DefaultClient, txSigner...)
+}
+
+// NewVaultKMSClientWithHTTPClient is an alternative of NewVaultKMSClient but uses the given http.Client
+// (e.g, with custom TLS settings) to talk to the Vault server.
+//
+// With AppRole, the client logs in again when its token is about to expire, or when a request is denied with it.
+func NewVaultKMSClientWithHTTPClient(ctx context.Context,
+	cfg Config,
+	httpClient *http.Client,
+	txSigner ...types.Signer,
+) (*VaultKMSClient, error) {
+	cfg = cfg.withEnvDefaults()
+	if _, err := cfg.IsValid(); err != nil {
+		return nil, fmt.Errorf("%w: %v", common2.ErrInvalidConfig, err)
+	}
+
+	signer := common2.NewTxSigner(cfg.ChainID, txSigner...)
+
+	c := &VaultKMSClient{httpClient: httpClient, ctx: ctx, cfg: cfg, token: cfg.Token, signer: signer}
+
+	if cfg.AppRole != nil {
+		c.appRole = &appRoleToken{}
+		if _, err := c.getToken(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	pubKey, keyVersion, err := c.getPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	c.publicKey = pubKey
+	c.keyVersion = keyVersion
+
+	return c, nil
+}
+
+// GetAddress returns the EVM address of the current signer.
+func (c VaultKMSClient) GetAddress() common.Address {
+	return crypto.PubkeyToAddress(*c.publicKey)
+}
+
+// GetPublicKey returns the public key of the selected version of the Transit key.
+func (c VaultKMSClient) GetPublicKey() (*ecdsa.PublicKey, error) {
+	return c.publicKey, nil
+}
+
+// GetKeyVersion returns the version of the Transit key used for signing.
+func (c VaultKMSClient) GetKeyVersion() int {
+	return c.keyVersion
+}
+
+// SignHash calls the remote Vault server to sign a given digested message.
+// The digest is sent as a pre-hashed input, so Vault does not care about which hash function has been used.
+func (c VaultKMSClient) SignHash(digest common.Hash) ([]byte, error) {
+	return c.SignHashWithContext(c.ctx, digest)
+}
+
+// SignHashWithContext is the same as SignHash, but the remote call is bound to the given context.
+func (c VaultKMSClient) SignHashWithContext(ctx context.Context, digest common.Hash) ([]byte, error) {
+	req := map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(digest[:]),
+		"prehashed":            true,
+		"hash_algorithm":       hashAlgorithm,
+		"marshaling_algorithm": marshalAlgorithm,
+		"key_version":          c.keyVersion,
+	}
+
+	var resp struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("%v/sign/%v", c.cfg.mountPath(), c.cfg.KeyName), req, &resp)
+	if err != nil {
+		return nil, wrapError("Sign", err)
+	}
+
+	return c.parseKMSSignature(digest, resp.Data.Signature)
+}
+
+// SignTypedData calls the remote Vault server to sign the EIP-712 digest of the given typed data.
+// If legacyV is set to true, the returned v will be either 27 or 28 (as returned by `eth_signTypedData_v4`).
+func (c VaultKMSClient) SignTypedData(typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return c.SignTypedDataWithContext(c.ctx, typedData, legacyV)
+}
+
+// SignTypedDataWithContext is the same as SignTypedData, but the remote call is bound to the given context.
+func (c VaultKMSClient) SignTypedDataWithContext(ctx context.Context, typedData core.TypedData, legacyV bool) ([]byte, error) {
+	return common2.SignTypedData(ctx, c.SignHashWithContext, typedData, legacyV)
+}
+
+// SignPersonalMessage calls the remote Vault server to sign the given message prefixed with
+// "\x19Ethereum Signed Message:\n" and its length (EIP-191). The returned v is either 27 or 28 (as returned by `personal_sign`).
+func (c VaultKMSClient) SignPersonalMessage(msg []byte) ([]byte, error) {
+	return c.SignPersonalMessageWithContext(c.ctx, msg)
+}
+
+// SignPersonalMessageWithContext is the same as SignPersonalMessage, but the remote call is bound to the given context.
+func (c VaultKMSClient) SignPersonalMessageWithContext(ctx context.Context, msg []byte) ([]byte, error) {
+	return common2.SignPersonalMessage(ctx, c.SignHashWithContext, msg)
+}
+
+// GetDefaultEVMTransactor returns the default KMS-backed instance of bind.TransactOpts.
+// Only `Context`, `From`, and `Signer` fields are set.
+func (c VaultKMSClient) GetDefaultEVMTransactor() *bind.TransactOpts {
+	return c.GetDefaultEVMTransactorWithContext(c.ctx)
+}
+
+// GetDefaultEVMTransactorWithContext is the same as GetDefaultEVMTransactor, but the given context is used for both
+// the transactor and the Vault calls made by its signer.
+func (c VaultKMSClient) GetDefaultEVMTransactorWithContext(ctx context.Context) *bind.TransactOpts {
+	return common2.NewTransactor(ctx, c.GetAddress(), c.GetEVMSignerFnWithContext(ctx))
+}
+
+// GetEVMSignerFn returns the EVM signer using the Vault Transit engine.
+func (c VaultKMSClient) GetEVMSignerFn() bind.SignerFn {
+	return c.GetEVMSignerFnWithContext(c.ctx)
+}
+
+// GetEVMSignerFnWithContext returns the EVM signer using the Vault Transit engine, whose remote calls are bound to the given context.
+func (c VaultKMSClient) GetEVMSignerFnWithContext(ctx context.Context) bind.SignerFn {
+	return common2.NewSignerFn(ctx, c.GetAddress(), c.signer, c.SignHashWithContext)
+}
+
+// HasSignedTx checks if the given tx is signed by the current VaultKMSClient.
+func (c VaultKMSClient) HasSignedTx(tx *types.Transaction) (bool, error) {
+	return common2.HasSignedTx(c.signer, c.GetAddress(), tx)
+}
+
+// WithSigner assigns the given signer to the VaultKMSClient.
+func (c *VaultKMSClient) WithSigner(signer types.Signer) {
+	c.signer = signer
+}
+
+// WithChainID assigns given chainID (and updates the corresponding signer) to the VaultKMSClient.
+func (c *VaultKMSClient) WithChainID(chainID *big.Int) {
+	c.cfg.ChainID, c.signer = common2.SwitchChainID(c.cfg.ChainID, c.signer, chainID)
+}
+
+// getToken returns the Vault token used to authenticate requests. With AppRole, the client logs in again if the
+// current token is about to expire, or if it is the given staleToken (e.g, a token which has just been denied).
+func (c VaultKMSClient) getToken(ctx context.Context, staleToken string) (string, error) {
+	if c.appRole == nil {
+		return c.token, nil
+	}
+
+	c.appRole.mtx.Lock()
+	defer c.appRole.mtx.Unlock()
+
+	if c.appRole.token != "" && c.appRole.token != staleToken &&
+		(c.appRole.renewAt.IsZero() || time.Now().Before(c.appRole.renewAt)) {
+		return c.appRole.token, nil
+	}
+
+	token, ttl, err := c.loginAppRole(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.appRole.token = token
+	c.appRole.renewAt = time.Time{}
+	if ttl > 0 {
+		c.appRole.renewAt = time.Now().Add(time.Duration(float64(ttl) * tokenRenewalRatio))
+	}
+
+	return token, nil
+}
+
+// loginAppRole exchanges the AppRole credentials for a Vault token, and returns the token and its TTL (zero if the
+// token does not expire).
+func (c VaultKMSClient) loginAppRole(ctx context.Context) (string, time.Duration, error) {
+	req := map[string]interface{}{
+		"role_id":   c.cfg.AppRole.RoleID,
+		"secret_id": c.cfg.AppRole.SecretID,
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int64  `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	err := c.doWithToken(ctx, "", http.MethodPost, fmt.Sprintf("auth/%v/login", c.cfg.AppRole.mountPath()), req, &resp)
+	if err != nil {
+		return "", 0, wrapError("Login", fmt.Errorf("failed to login with AppRole: %w", err))
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", 0, fmt.Errorf("%w: failed to login with AppRole: empty client token", common2.ErrPermissionDenied)
+	}
+
+	return resp.Auth.ClientToken, time.Duration(resp.Auth.LeaseDuration) * time.Second, nil
+}
+
+// getPublicKey retrieves the public key of the configured (or latest) version of the Transit key.
+func (c VaultKMSClient) getPublicKey() (*ecdsa.PublicKey, int, error) {
+	var resp struct {
+		Data struct {
+			Type          string `json:"type"`
+			LatestVersion int    `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	err := c.do(c.ctx, http.MethodGet, fmt.Sprintf("%v/keys/%v", c.cfg.mountPath(), c.cfg.KeyName), nil, &resp)
+	if err != nil {
+		return nil, 0, wrapError("ReadKey", fmt.Errorf("failed to get public key from Vault for KeyName=%v: %w", c.cfg.KeyName, err))
+	}
+
+	if resp.Data.Type != keyType {
+		return nil, 0, fmt.Errorf("%w: unsupported key type %v, expected %v", common2.ErrWrongKeySpec, resp.Data.Type, keyType)
+	}
+
+	keyVersion := c.cfg.KeyVersion
+	if keyVersion == 0 {
+		keyVersion = resp.Data.LatestVersion
+	}
+
+	key, ok := resp.Data.Keys[strconv.Itoa(keyVersion)]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: key version %v not found for KeyName=%v", common2.ErrKeyNotFound, keyVersion, c.cfg.KeyName)
+	}
+
+	pubKey, err := parseKMSPublicKey(key.PublicKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return pubKey, keyVersion, nil
+}
+
+// do performs an authenticated request to the Vault HTTP API and decodes the response into the given result. With
+// AppRole, a denied request is retried once with a new token.
+func (c VaultKMSClient) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	token, err := c.getToken(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	err = c.doWithToken(ctx, token, method, path, body, result)
+	if c.appRole == nil || errorKind(err) != common2.ErrPermissionDenied {
+		return err
+	}
+
+	// the token may have been revoked, or have expired before its TTL
+	if token, err = c.getToken(ctx, token); err != nil {
+		return err
+	}
+
+	return c.doWithToken(ctx, token, method, path, body, result)
+}
+
+// doWithToken performs a request to the Vault HTTP API with the given token (if any) and decodes the response into
+// the given result.
+func (c VaultKMSClient) doWithToken(ctx context.Context,
+	token string,
+	method, path string,
+	body interface{},
+	result interface{},
+) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	url := fmt.Sprintf("%v/v1/%v", strings.TrimRight(c.cfg.Address, "/"), path)
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.cfg.Namespace)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(respBody, &errResp)
+		return &apiError{StatusCode: resp.StatusCode, Errors: errResp.Errors}
+	}
+
+	return json.Unmarshal(respBody, result)
+}
+
+// parseKMSSignature parses a signature returned from the Vault Transit engine (`vault:v<version>:<base64 DER>`)
+// to a valid EVM-compatible signature.
+// A valid EVM signature is a 65-byte long RLP-encoded of the form R || S || V (https://eips.ethereum.org/EIPS/eip-155).
+func (c VaultKMSClient) parseKMSSignature(digestedMsg common.Hash,
+	vaultSignature string,
+) ([]byte, error) {
+	if !strings.HasPrefix(vaultSignature, signaturePrefix) {
+		return nil, fmt.Errorf("%w: invalid vault signature %v", common2.ErrSignatureInvalid, vaultSignature)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(vaultSignature, signaturePrefix), ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w: invalid vault signature %v", common2.ErrSignatureInvalid, vaultSignature)
+	}
+	if version, err := strconv.Atoi(parts[0]); err != nil || version != c.keyVersion {
+		return nil, fmt.Errorf("%w: unexpected key version in vault signature %v, expected %v",
+			common2.ErrSignatureInvalid, vaultSignature, c.keyVersion)
+	}
+
+	kmsSignature, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decode vault signature: %v", common2.ErrSignatureInvalid, err)
+	}
+
+	// recover r, s
+	var sig common2.KmsSignature
+	_, err = asn1.Unmarshal(kmsSignature, &sig)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot unmarshal kms signature: %v", common2.ErrSignatureInvalid, err)
+	}
+
+	// convert the signature into a valid EVM signature.
+	return common2.KmsToEVMSignature(*c.publicKey, sig, digestedMsg)
+}
+
+// parseKMSPublicKey parses a PEM-encoded public key returned from the Vault Transit engine to a valid ecdsa.PublicKey.
+func parseKMSPublicKey(pemPubKey string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemPubKey))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("cannot decode public key %v", pemPubKey)
+	}
+
+	return common2.ParsePKIXPublicKey(block.Bytes)
+}
@@ -0,0 +1,375 @@
+package vaultkms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	common2 "github.com/LampardNguyen234/evm-kms/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testToken    = "s.test-token"
+	testRoleID   = "test-role-id"
+	testSecretID = "test-secret-id"
+	testKeyName  = "evm-ecdsa"
+)
+
+var receiverAddr = common.HexToAddress("0x243e9517a24813a2d73e9a74cd2c1c699d0ff7a5")
+
+// transitServer is a minimal stand-in for the Vault Transit API, backed by in-memory secp256k1 keys.
+type transitServer struct {
+	keys []*ecdsa.PrivateKey
+
+	mtx sync.Mutex
+	// leaseDuration is the TTL (in seconds) of the tokens issued via AppRole.
+	leaseDuration int
+	// tokens are the valid tokens issued via AppRole.
+	tokens    map[string]bool
+	numLogins int
+}
+
+func newTransitServer(numVersions int) *httptest.Server {
+	_, server := startTransitServer(numVersions)
+	return server
+}
+
+func startTransitServer(numVersions int) (*transitServer, *httptest.Server) {
+	s := &transitServer{tokens: make(map[string]bool)}
+	for i := 0; i < numVersions; i++ {
+		privateKey, err := crypto.GenerateKey()
+		if err != nil {
+			panic(err)
+		}
+		s.keys = append(s.keys, privateKey)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", s.handleLogin)
+	mux.HandleFunc("/v1/transit/keys/"+testKeyName, s.authenticated(s.handleReadKey))
+	mux.HandleFunc("/v1/transit/sign/"+testKeyName, s.authenticated(s.handleSign))
+
+	return s, httptest.NewServer(mux)
+}
+
+// revokeTokens revokes all the tokens issued via AppRole.
+func (s *transitServer) revokeTokens() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.tokens = make(map[string]bool)
+}
+
+func (s *transitServer) getNumLogins() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.numLogins
+}
+
+func (s *transitServer) writeError(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{msg}})
+}
+
+func (s *transitServer) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Vault-Token")
+		s.mtx.Lock()
+		valid := token == testToken || s.tokens[token]
+		s.mtx.Unlock()
+		if !valid {
+			s.writeError(w, http.StatusForbidden, "permission denied")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *transitServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req["role_id"] != testRoleID || req["secret_id"] != testSecretID {
+		s.writeError(w, http.StatusBadRequest, "invalid role or secret ID")
+		return
+	}
+
+	s.mtx.Lock()
+	s.numLogins++
+	token := fmt.Sprintf("s.approle-token-%v", s.numLogins)
+	s.tokens[token] = true
+	leaseDuration := s.leaseDuration
+	s.mtx.Unlock()
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"auth": map[string]interface{}{"client_token": token, "lease_duration": leaseDuration},
+	})
+}
+
+func (s *transitServer) handleReadKey(w http.ResponseWriter, r *http.Request) {
+	keys := make(map[string]interface{})
+	for i, privateKey := range s.keys {
+		der, err := common2.MarshalPKIXPublicKey(&privateKey.PublicKey)
+		if err != nil {
+			panic(err)
+		}
+		keys[strconv.Itoa(i+1)] = map[string]interface{}{
+			"public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		}
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{
+			"name":           testKeyName,
+			"type":           keyType,
+			"latest_version": len(s.keys),
+			"keys":           keys,
+		},
+	})
+}
+
+func (s *transitServer) handleSign(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Input      string `json:"input"`
+		Prehashed  bool   `json:"prehashed"`
+		KeyVersion int    `json:"key_version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !req.Prehashed || req.KeyVersion < 1 || req.KeyVersion > len(s.keys) {
+		s.writeError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	digest, err := base64.StdEncoding.DecodeString(req.Input)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sig, err := crypto.Sign(digest, s.keys[req.KeyVersion-1])
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// like any generic ECDSA implementation, Vault does not enforce a low S; return the high-S form to exercise
+	// the normalization.
+	r2 := new(big.Int).SetBytes(sig[:32])
+	s2 := new(big.Int).Sub(common2.CurveOrder, new(big.Int).SetBytes(sig[32:64]))
+	der, err := asn1.Marshal(common2.KmsSignature{R: r2, S: s2})
+	if err != nil {
+		panic(err)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{
+			"signature": fmt.Sprintf("vault:v%v:%v", req.KeyVersion, base64.StdEncoding.EncodeToString(der)),
+		},
+	})
+}
+
+func TestNewVaultKMSClient(t *testing.T) {
+	server := newTransitServer(2)
+	defer server.Close()
+
+	// token auth, latest version
+	c, err := NewVaultKMSClient(context.Background(), Config{
+		Address: server.URL,
+		KeyName: testKeyName,
+		Token:   testToken,
+		ChainID: 1,
+	})
+	if err != nil {
+		panic(err)
+	}
+	if c.GetKeyVersion() != 2 {
+		panic(fmt.Sprintf("expected key version 2, got %v", c.GetKeyVersion()))
+	}
+
+	// AppRole auth, fixed version
+	c1, err := NewVaultKMSClient(context.Background(), Config{
+		Address:    server.URL,
+		KeyName:    testKeyName,
+		KeyVersion: 1,
+		AppRole:    &AppRoleConfig{RoleID: testRoleID, SecretID: testSecretID},
+		ChainID:    1,
+	})
+	if err != nil {
+		panic(err)
+	}
+	if c1.GetAddress() == c.GetAddress() {
+		panic("expected different addresses for different key versions")
+	}
+
+	// wrong token
+	_, err = NewVaultKMSClient(context.Background(), Config{
+		Address: server.URL,
+		KeyName: testKeyName,
+		Token:   "s.wrong-token",
+	})
+	if !errors.Is(err, common2.ErrPermissionDenied) {
+		panic(fmt.Sprintf("expected %v with a wrong token, got %v", common2.ErrPermissionDenied, err))
+	}
+
+	// non-existing version
+	_, err = NewVaultKMSClient(context.Background(), Config{
+		Address:    server.URL,
+		KeyName:    testKeyName,
+		KeyVersion: 3,
+		Token:      testToken,
+	})
+	if !errors.Is(err, common2.ErrKeyNotFound) {
+		panic(fmt.Sprintf("expected %v with a non-existing key version, got %v", common2.ErrKeyNotFound, err))
+	}
+
+	// non-existing key
+	_, err = NewVaultKMSClient(context.Background(), Config{
+		Address: server.URL,
+		KeyName: "unknown-key",
+		Token:   testToken,
+	})
+	if !errors.Is(err, common2.ErrKeyNotFound) {
+		panic(fmt.Sprintf("expected %v with a non-existing key, got %v", common2.ErrKeyNotFound, err))
+	}
+}
+
+func TestNewVaultKMSClient_Env(t *testing.T) {
+	server := newTransitServer(1)
+	defer server.Close()
+
+	if err := os.Setenv("VAULT_ADDR", server.URL); err != nil {
+		panic(err)
+	}
+	defer os.Unsetenv("VAULT_ADDR")
+	if err := os.Setenv("VAULT_TOKEN", testToken); err != nil {
+		panic(err)
+	}
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	cfg := Config{KeyName: testKeyName, ChainID: 1}
+	if _, err := cfg.IsValid(); err != nil {
+		panic(err)
+	}
+	if _, err := NewVaultKMSClient(context.Background(), cfg); err != nil {
+		panic(err)
+	}
+
+	os.Unsetenv("VAULT_TOKEN")
+	if _, err := cfg.IsValid(); err == nil {
+		panic("expected an error without Token and VAULT_TOKEN")
+	}
+}
+
+func TestVaultKMSClient_AppRoleRenewal(t *testing.T) {
+	s, server := startTransitServer(1)
+	defer server.Close()
+	s.leaseDuration = 1
+
+	c, err := NewVaultKMSClient(context.Background(), Config{
+		Address: server.URL,
+		KeyName: testKeyName,
+		AppRole: &AppRoleConfig{RoleID: testRoleID, SecretID: testSecretID},
+		ChainID: 1,
+	})
+	if err != nil {
+		panic(err)
+	}
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+
+	// a revoked token is replaced once denied
+	s.revokeTokens()
+	if _, err = c.SignHash(digest); err != nil {
+		panic(err)
+	}
+	if s.getNumLogins() != 2 {
+		panic(fmt.Sprintf("expected 2 logins, got %v", s.getNumLogins()))
+	}
+
+	// a token is replaced before it expires
+	time.Sleep(900 * time.Millisecond)
+	if _, err = c.SignHash(digest); err != nil {
+		panic(err)
+	}
+	if s.getNumLogins() != 3 {
+		panic(fmt.Sprintf("expected 3 logins, got %v", s.getNumLogins()))
+	}
+}
+
+func TestVaultKMSClient_Sign(t *testing.T) {
+	server := newTransitServer(1)
+	defer server.Close()
+
+	c, err := NewVaultKMSClient(context.Background(), Config{
+		Address: server.URL,
+		KeyName: testKeyName,
+		Token:   testToken,
+		ChainID: 80001,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	digest := crypto.Keccak256Hash([]byte("Hello World"))
+	sig, err := c.SignHash(digest)
+	if err != nil {
+		panic(err)
+	}
+	if new(big.Int).SetBytes(sig[32:64]).Cmp(common2.CurveOrderHalf) > 0 {
+		panic("expected a low-S signature")
+	}
+
+	pubKey, err := crypto.SigToPub(digest[:], sig)
+	if err != nil {
+		panic(err)
+	}
+	if crypto.PubkeyToAddress(*pubKey) != c.GetAddress() {
+		panic("invalid signature")
+	}
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = c.SignHashWithContext(cancelledCtx, digest); !errors.Is(err, context.Canceled) {
+		panic(fmt.Sprintf("expected %v, got %v", context.Canceled, err))
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(80001),
+		To:        &receiverAddr,
+		GasTipCap: big.NewInt(1000000),
+		GasFeeCap: big.NewInt(2000000),
+		Gas:       50000,
+		Value:     big.NewInt(100),
+	})
+	signedTx, err := c.GetDefaultEVMTransactor().Signer(c.GetAddress(), tx)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = c.HasSignedTx(signedTx); err != nil {
+		panic(err)
+	}
+
+	if _, err = c.GetEVMSignerFn()(receiverAddr, tx); err != bind.ErrNotAuthorized {
+		panic(fmt.Sprintf("expected %v, got %v", bind.ErrNotAuthorized, err))
+	}
+}